@@ -0,0 +1,158 @@
+package audioutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// IsAIFF reports whether data starts with an AIFF or AIFF-C header.
+func IsAIFF(data []byte) bool {
+	return len(data) >= 12 && bytes.Equal(data[0:4], []byte("FORM")) &&
+		(bytes.Equal(data[8:12], []byte("AIFF")) || bytes.Equal(data[8:12], []byte("AIFC")))
+}
+
+// ReadAIFF reads the AIFF file at path, as decoded by DecodeAIFF.
+func ReadAIFF(path string) ([]float32, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return DecodeAIFF(data)
+}
+
+// DecodeAIFF decodes an AIFF file holding 8, 16, 24 or 32-bit integer PCM samples,
+// returning the samples normalized to the [-1, 1] range along with their sample rate.
+// AIFF-C files are supported as well when uncompressed, with big-endian ("NONE" or
+// "twos") or little-endian ("sowt") integer samples or 32-bit float ("fl32") ones.
+// Multichannel audio is mixed down to mono by averaging the channels.
+func DecodeAIFF(data []byte) ([]float32, int, error) {
+	if !IsAIFF(data) {
+		return nil, 0, fmt.Errorf("invalid AIFF data: missing FORM header")
+	}
+
+	var (
+		channels   int
+		bits       int
+		sampleRate int
+		order      binary.ByteOrder = binary.BigEndian
+		float      bool
+		pcm        []byte
+		foundComm  bool
+		foundSsnd  bool
+	)
+
+	isAIFC := bytes.Equal(data[8:12], []byte("AIFC"))
+	for rest := data[12:]; len(rest) >= 8; {
+		id := string(rest[0:4])
+		size := int(binary.BigEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		size = min(size, len(rest))
+		chunk := rest[:size]
+
+		switch id {
+		case "COMM":
+			if len(chunk) < 18 || (isAIFC && len(chunk) < 22) {
+				return nil, 0, fmt.Errorf("invalid AIFF data: COMM chunk too short")
+			}
+			channels = int(binary.BigEndian.Uint16(chunk[0:2]))
+			bits = int(binary.BigEndian.Uint16(chunk[6:8]))
+			sampleRate = int(math.Round(decodeExtended(chunk[8:18])))
+
+			if isAIFC {
+				switch compression := string(chunk[18:22]); compression {
+				case "NONE", "twos":
+				case "sowt":
+					order = binary.LittleEndian
+				case "fl32", "FL32":
+					float = true
+				default:
+					return nil, 0, fmt.Errorf("unsupported AIFF-C compression %q", compression)
+				}
+			}
+
+			if float && bits != 32 {
+				return nil, 0, fmt.Errorf("unsupported AIFF format with %d bits per float sample", bits)
+			}
+			if bits < 1 || bits > 32 {
+				return nil, 0, fmt.Errorf("unsupported AIFF format with %d bits per sample", bits)
+			}
+			if channels < 1 {
+				return nil, 0, fmt.Errorf("invalid AIFF data: no channels")
+			}
+			if sampleRate <= 0 {
+				return nil, 0, fmt.Errorf("invalid AIFF data: invalid sample rate")
+			}
+			foundComm = true
+		case "SSND":
+			if len(chunk) < 8 {
+				return nil, 0, fmt.Errorf("invalid AIFF data: SSND chunk too short")
+			}
+			// The samples start after the offset following the chunk header.
+			offset := int(binary.BigEndian.Uint32(chunk[0:4]))
+			pcm = chunk[min(8+offset, len(chunk)):]
+			foundSsnd = true
+		}
+
+		// Chunks are padded to an even size.
+		rest = rest[min(size+size%2, len(rest)):]
+	}
+
+	if !foundComm {
+		return nil, 0, fmt.Errorf("invalid AIFF data: missing COMM chunk")
+	}
+	if !foundSsnd {
+		return nil, 0, fmt.Errorf("invalid AIFF data: missing SSND chunk")
+	}
+
+	// Samples are stored in whole bytes, left-justified, and a trailing partial frame
+	// is ignored.
+	width := (bits + 7) / 8
+	frames := len(pcm) / (width * channels)
+	samples := make([]float32, frames)
+	var buf [4]byte
+	for i := range samples {
+		var sum float32
+		for c := 0; c < channels; c++ {
+			b := pcm[(i*channels+c)*width:][:width]
+			// Widen to 32 bits, keeping the sample left-justified.
+			clear(buf[:])
+			if order == binary.BigEndian {
+				copy(buf[:], b)
+			} else {
+				for j := range b {
+					buf[width-1-j] = b[j]
+				}
+			}
+			v := binary.BigEndian.Uint32(buf[:])
+			if float {
+				sum += math.Float32frombits(v)
+			} else {
+				sum += float32(float64(int32(v)) / (1 << 31))
+			}
+		}
+		samples[i] = sum / float32(channels)
+	}
+
+	return samples, sampleRate, nil
+}
+
+// decodeExtended decodes the 80-bit IEEE 754 extended precision value of b, as used
+// for the sample rate of AIFF files.
+func decodeExtended(b []byte) float64 {
+	sign := 1.0
+	if b[0]&0x80 != 0 {
+		sign = -1
+	}
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7fff)
+	mant := binary.BigEndian.Uint64(b[2:10])
+
+	if exp == 0 && mant == 0 {
+		return 0
+	}
+	// The mantissa has an explicit integer bit, hence the additional 63 bits shift.
+	return sign * math.Ldexp(float64(mant), exp-16383-63)
+}
@@ -0,0 +1,142 @@
+package audioutil
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encodeAIFF builds an AIFF file, or an AIFF-C one if compression is set, with a
+// COMM chunk of the given parameters followed by the SSND chunk.
+func encodeAIFF(channels, bits int, sampleRate []byte, compression string, pcm []byte) []byte {
+	frames := len(pcm) / (channels * ((bits + 7) / 8))
+
+	comm := binary.BigEndian.AppendUint16(nil, uint16(channels))
+	comm = binary.BigEndian.AppendUint32(comm, uint32(frames))
+	comm = binary.BigEndian.AppendUint16(comm, uint16(bits))
+	comm = append(comm, sampleRate...)
+	formType := "AIFF"
+	if compression != "" {
+		formType = "AIFC"
+		comm = append(comm, compression...)
+		// An empty pascal string as compression name, padded to an even size.
+		comm = append(comm, 0, 0)
+	}
+
+	// An offset of 2 bytes before the samples.
+	ssnd := binary.BigEndian.AppendUint32(nil, 2)
+	ssnd = binary.BigEndian.AppendUint32(ssnd, 0)
+	ssnd = append(ssnd, 0xaa, 0xbb)
+	ssnd = append(ssnd, pcm...)
+
+	var body []byte
+	body = append(body, formType...)
+	// An unrelated chunk with an odd size, which gets padded.
+	body = append(body, "ANNO"...)
+	body = binary.BigEndian.AppendUint32(body, 3)
+	body = append(body, 'a', 'b', 'c', 0)
+	body = append(body, "COMM"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(comm)))
+	body = append(body, comm...)
+	body = append(body, "SSND"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(ssnd)))
+	body = append(body, ssnd...)
+
+	data := []byte("FORM")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(body)))
+	return append(data, body...)
+}
+
+// The 80-bit extended representations of common sample rates.
+var (
+	rate16000 = []byte{0x40, 0x0c, 0xfa, 0, 0, 0, 0, 0, 0, 0}
+	rate44100 = []byte{0x40, 0x0e, 0xac, 0x44, 0, 0, 0, 0, 0, 0}
+)
+
+func TestDecodeAIFF(t *testing.T) {
+	t.Run("int16 mono", func(t *testing.T) {
+		pcm := binary.BigEndian.AppendUint16(nil, 0x4000)
+		pcm = binary.BigEndian.AppendUint16(pcm, 0xc000)
+		pcm = binary.BigEndian.AppendUint16(pcm, 0x7fff)
+
+		samples, sampleRate, err := DecodeAIFF(encodeAIFF(1, 16, rate16000, "", pcm))
+		require.NoError(t, err)
+		require.Equal(t, 16000, sampleRate)
+		require.InDeltaSlice(t, []float32{0.5, -0.5, 32767.0 / 32768}, samples, 1e-6)
+	})
+
+	t.Run("int24 stereo", func(t *testing.T) {
+		pcm := []byte{
+			0x40, 0x00, 0x00, 0x20, 0x00, 0x00,
+			0xc0, 0x00, 0x00, 0xe0, 0x00, 0x00,
+			// A trailing partial frame.
+			0x40, 0x00, 0x00,
+		}
+
+		samples, sampleRate, err := DecodeAIFF(encodeAIFF(2, 24, rate44100, "", pcm))
+		require.NoError(t, err)
+		require.Equal(t, 44100, sampleRate)
+		require.InDeltaSlice(t, []float32{0.375, -0.375}, samples, 1e-6)
+	})
+
+	t.Run("int8", func(t *testing.T) {
+		samples, _, err := DecodeAIFF(encodeAIFF(1, 8, rate16000, "", []byte{0x40, 0x80}))
+		require.NoError(t, err)
+		require.InDeltaSlice(t, []float32{0.5, -1}, samples, 1e-6)
+	})
+
+	t.Run("aifc sowt", func(t *testing.T) {
+		pcm := binary.LittleEndian.AppendUint16(nil, 0x4000)
+		pcm = binary.LittleEndian.AppendUint16(pcm, 0xc000)
+
+		samples, sampleRate, err := DecodeAIFF(encodeAIFF(1, 16, rate16000, "sowt", pcm))
+		require.NoError(t, err)
+		require.Equal(t, 16000, sampleRate)
+		require.InDeltaSlice(t, []float32{0.5, -0.5}, samples, 1e-6)
+	})
+
+	t.Run("aifc fl32", func(t *testing.T) {
+		pcm := binary.BigEndian.AppendUint32(nil, math.Float32bits(0.25))
+		pcm = binary.BigEndian.AppendUint32(pcm, math.Float32bits(-0.75))
+
+		samples, _, err := DecodeAIFF(encodeAIFF(1, 32, rate16000, "fl32", pcm))
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.25, -0.75}, samples)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := DecodeAIFF([]byte("RIFF\x00\x00\x00\x00WAVE"))
+		require.EqualError(t, err, "invalid AIFF data: missing FORM header")
+
+		_, _, err = DecodeAIFF(encodeAIFF(1, 16, rate16000, "ima4", nil))
+		require.EqualError(t, err, `unsupported AIFF-C compression "ima4"`)
+
+		_, _, err = DecodeAIFF(encodeAIFF(1, 16, rate16000, "", nil)[:50])
+		require.EqualError(t, err, "invalid AIFF data: missing SSND chunk")
+	})
+}
+
+func TestReadAIFF(t *testing.T) {
+	pcm := binary.BigEndian.AppendUint16(nil, 0x4000)
+	path := filepath.Join(t.TempDir(), "samples.aiff")
+	require.NoError(t, os.WriteFile(path, encodeAIFF(1, 16, rate16000, "", pcm), 0o644))
+
+	samples, sampleRate, err := ReadAIFF(path)
+	require.NoError(t, err)
+	require.Equal(t, 16000, sampleRate)
+	require.Equal(t, []float32{0.5}, samples)
+
+	_, _, err = ReadAIFF(filepath.Join(t.TempDir(), "missing.aiff"))
+	require.Error(t, err)
+}
+
+func TestDecodeExtended(t *testing.T) {
+	require.Equal(t, 16000.0, decodeExtended(rate16000))
+	require.Equal(t, 44100.0, decodeExtended(rate44100))
+	require.Equal(t, 8000.0, decodeExtended([]byte{0x40, 0x0b, 0xfa, 0, 0, 0, 0, 0, 0, 0}))
+	require.Zero(t, decodeExtended(make([]byte, 10)))
+}
@@ -0,0 +1,64 @@
+package audioutil
+
+// The samples decoded from each G.711 code, normalized to the [-1, 1] range.
+var (
+	muLawTable = g711Table(decodeMuLaw)
+	aLawTable  = g711Table(decodeALaw)
+)
+
+func g711Table(decode func(code byte) int16) [256]float32 {
+	var table [256]float32
+	for i := range table {
+		table[i] = float32(decode(byte(i))) / 32768
+	}
+	return table
+}
+
+// DecodeMuLaw decodes G.711 µ-law audio, one byte per sample as used by North American
+// and Japanese telephony, returning the samples normalized to the [-1, 1] range.
+func DecodeMuLaw(data []byte) []float32 {
+	return decodeG711(data, &muLawTable)
+}
+
+// DecodeALaw decodes G.711 A-law audio, one byte per sample as used by European
+// telephony, returning the samples normalized to the [-1, 1] range.
+func DecodeALaw(data []byte) []float32 {
+	return decodeG711(data, &aLawTable)
+}
+
+func decodeG711(data []byte, table *[256]float32) []float32 {
+	samples := make([]float32, len(data))
+	for i, code := range data {
+		samples[i] = table[code]
+	}
+	return samples
+}
+
+// decodeMuLaw returns the 16-bit linear sample encoded by a µ-law code.
+func decodeMuLaw(code byte) int16 {
+	code = ^code
+	exponent := (code >> 4) & 0x07
+	mantissa := int16(code & 0x0F)
+	sample := ((mantissa << 3) + 0x84) << exponent
+	sample -= 0x84
+	if code&0x80 != 0 {
+		return -sample
+	}
+	return sample
+}
+
+// decodeALaw returns the 16-bit linear sample encoded by an A-law code.
+func decodeALaw(code byte) int16 {
+	code ^= 0x55
+	exponent := (code >> 4) & 0x07
+	mantissa := int16(code & 0x0F)
+	sample := (mantissa << 4) + 8
+	if exponent > 0 {
+		sample = (sample + 0x100) << (exponent - 1)
+	}
+	// Unlike µ-law, a set sign bit denotes a positive sample.
+	if code&0x80 == 0 {
+		return -sample
+	}
+	return sample
+}
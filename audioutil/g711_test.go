@@ -0,0 +1,31 @@
+package audioutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeG711(t *testing.T) {
+	t.Run("mu-law", func(t *testing.T) {
+		samples := DecodeMuLaw([]byte{0xFF, 0x7F, 0x80, 0x00, 0xFE})
+		require.Equal(t, []float32{0, 0, 32124.0 / 32768, -32124.0 / 32768, 8.0 / 32768}, samples)
+	})
+
+	t.Run("a-law", func(t *testing.T) {
+		samples := DecodeALaw([]byte{0xD5, 0x55, 0xAA, 0x2A})
+		require.Equal(t, []float32{8.0 / 32768, -8.0 / 32768, 32256.0 / 32768, -32256.0 / 32768}, samples)
+	})
+
+	t.Run("monotonic", func(t *testing.T) {
+		// Positive µ-law codes decrease as the magnitude grows from 0xFF down to 0x80.
+		for code := 0xFF; code > 0x80; code-- {
+			require.Less(t, muLawTable[code], muLawTable[code-1])
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, DecodeMuLaw(nil))
+		require.Empty(t, DecodeALaw(nil))
+	})
+}
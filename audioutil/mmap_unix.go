@@ -0,0 +1,125 @@
+//go:build unix
+
+package audioutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"syscall"
+)
+
+// MmapReader reads raw float32 little-endian samples from a memory mapped file, so that
+// recordings too large to fit in memory can be processed window by window, e.g. by
+// feeding a speech.StreamDetector:
+//
+//	r, err := audioutil.OpenMmap("recording.pcm")
+//	if err != nil {
+//		return err
+//	}
+//	defer r.Close()
+//
+//	buf := make([]float32, 16000)
+//	for {
+//		n, err := r.Read(buf)
+//		if n > 0 {
+//			if _, err := stream.Process(buf[:n]); err != nil {
+//				return err
+//			}
+//		}
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//	}
+//
+// Only the pages being read are loaded in memory, and they can be reclaimed by the
+// operating system afterwards. A trailing partial sample, if any, is ignored.
+type MmapReader struct {
+	data   []byte
+	pos    int
+	closed bool
+}
+
+// OpenMmap memory maps the file at path for reading its samples with an MmapReader,
+// which must be closed to unmap the file.
+func OpenMmap(path string) (*MmapReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	// The mapping stays valid after closing the file.
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	size := info.Size()
+	if size > math.MaxInt {
+		return nil, fmt.Errorf("file too large to map: %d bytes", size)
+	}
+
+	r := &MmapReader{}
+	// Empty files can't be mapped, and have no samples to read anyway.
+	if size == 0 {
+		return r, nil
+	}
+
+	r.data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map file: %w", err)
+	}
+
+	return r, nil
+}
+
+// Len returns the number of samples in the file.
+func (r *MmapReader) Len() int {
+	return len(r.data) / 4
+}
+
+// Read reads up to len(dst) samples into dst, returning the number of samples read.
+// It returns io.EOF once all the samples have been read.
+func (r *MmapReader) Read(dst []float32) (int, error) {
+	if r.closed {
+		return 0, fmt.Errorf("read from closed reader: %w", os.ErrClosed)
+	}
+
+	n := min(len(dst), r.Len()-r.pos/4)
+	if n == 0 && len(dst) > 0 {
+		return 0, io.EOF
+	}
+
+	for i := range dst[:n] {
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(r.data[r.pos:]))
+		r.pos += 4
+	}
+
+	return n, nil
+}
+
+// Close unmaps the file. Calling it more than once has no effect.
+func (r *MmapReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	data := r.data
+	r.data = nil
+	if data == nil {
+		return nil
+	}
+
+	if err := syscall.Munmap(data); err != nil {
+		return fmt.Errorf("failed to unmap file: %w", err)
+	}
+
+	return nil
+}
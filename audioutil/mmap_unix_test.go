@@ -0,0 +1,70 @@
+//go:build unix
+
+package audioutil
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMmapReader(t *testing.T) {
+	// writeSamples writes samples as raw float32 little-endian data, followed by extra
+	// bytes, returning the file path.
+	writeSamples := func(t *testing.T, samples []float32, extra int) string {
+		data := make([]byte, len(samples)*4+extra)
+		for i, sample := range samples {
+			binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(sample))
+		}
+		path := filepath.Join(t.TempDir(), "samples.pcm")
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return path
+	}
+
+	samples := sweep(10000, 16000, 100, 4000)
+
+	t.Run("read", func(t *testing.T) {
+		r, err := OpenMmap(writeSamples(t, samples, 3))
+		require.NoError(t, err)
+		require.Equal(t, len(samples), r.Len())
+
+		var read []float32
+		buf := make([]float32, 512)
+		for {
+			n, err := r.Read(buf)
+			read = append(read, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			require.NotZero(t, n)
+		}
+		require.Equal(t, samples, read)
+
+		require.NoError(t, r.Close())
+		_, err = r.Read(buf)
+		require.ErrorIs(t, err, os.ErrClosed)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		r, err := OpenMmap(writeSamples(t, nil, 0))
+		require.NoError(t, err)
+		require.Zero(t, r.Len())
+
+		n, err := r.Read(make([]float32, 512))
+		require.Zero(t, n)
+		require.Equal(t, io.EOF, err)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := OpenMmap(filepath.Join(t.TempDir(), "missing.pcm"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
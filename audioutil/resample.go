@@ -0,0 +1,143 @@
+// Package audioutil provides helpers to prepare audio for speech detection.
+package audioutil
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// The number of zero crossings of the sinc on each side of the filter, which
+	// trades sharpness of the cutoff for speed.
+	zeroCrossings = 16
+	// The Kaiser window shape parameter, giving a stopband attenuation around 80dB.
+	kaiserBeta = 8.0
+	// The cutoff frequency relative to the lower Nyquist frequency, leaving room for
+	// the transition band so that aliasing is kept low.
+	rolloff = 0.95
+	// The maximum number of filter phases to precompute. Rate ratios needing more
+	// compute the filter taps as needed instead.
+	maxPhases = 1024
+)
+
+// Resample converts in from fromRate to toRate, e.g. to bring audio to the 16kHz
+// expected by the model. It uses polyphase windowed sinc interpolation, low-pass
+// filtering the signal when downsampling to avoid aliasing.
+//
+// Both rates must be positive, Resample panics otherwise: rates coming from untrusted
+// input, such as file headers, should be validated beforehand.
+func Resample(in []float32, fromRate, toRate int) []float32 {
+	if fromRate <= 0 || toRate <= 0 {
+		panic(fmt.Sprintf("invalid sample rates: %d to %d", fromRate, toRate))
+	}
+
+	if fromRate == toRate || len(in) == 0 {
+		return append([]float32(nil), in...)
+	}
+
+	// Output sample n lies at input position n*down/up.
+	g := gcd(fromRate, toRate)
+	up, down := toRate/g, fromRate/g
+
+	f := newFilter(up, down)
+	out := make([]float32, (len(in)*up+down-1)/down)
+	for n := range out {
+		pos := n * down
+		base, phase := pos/up, pos%up
+		taps := f.taps(phase)
+
+		var acc float64
+		for j, tap := range taps {
+			i := base - f.halfWidth + 1 + j
+			if i < 0 || i >= len(in) {
+				continue
+			}
+			acc += float64(in[i]) * tap
+		}
+		out[n] = float32(acc)
+	}
+
+	return out
+}
+
+// filter is a bank of low-pass filters, one per output phase.
+type filter struct {
+	up        int
+	cutoff    float64
+	halfWidth int
+	// The precomputed taps of every phase, if any.
+	phases [][]float64
+	// Scratch buffer for the taps when they are not precomputed.
+	buf []float64
+}
+
+func newFilter(up, down int) *filter {
+	cutoff := rolloff * min(1, float64(up)/float64(down))
+	f := &filter{
+		up:        up,
+		cutoff:    cutoff,
+		halfWidth: int(math.Ceil(zeroCrossings / cutoff)),
+	}
+
+	if up <= maxPhases {
+		f.phases = make([][]float64, up)
+		for phase := range f.phases {
+			f.phases[phase] = f.computeTaps(phase, nil)
+		}
+	}
+
+	return f
+}
+
+// taps returns the filter taps for phase, applying to the input samples from
+// base-halfWidth+1 to base+halfWidth.
+func (f *filter) taps(phase int) []float64 {
+	if f.phases != nil {
+		return f.phases[phase]
+	}
+	f.buf = f.computeTaps(phase, f.buf)
+	return f.buf
+}
+
+func (f *filter) computeTaps(phase int, taps []float64) []float64 {
+	taps = taps[:0]
+	frac := float64(phase) / float64(f.up)
+	for k := -f.halfWidth + 1; k <= f.halfWidth; k++ {
+		// Distance in input samples between the tap and the output position.
+		t := float64(k) - frac
+		taps = append(taps, f.cutoff*sinc(f.cutoff*t)*kaiser(t/float64(f.halfWidth)))
+	}
+	return taps
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser returns the Kaiser window value at x, in the [-1, 1] range.
+func kaiser(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return besselI0(kaiserBeta*math.Sqrt(1-x*x)) / besselI0(kaiserBeta)
+}
+
+// besselI0 computes the zeroth order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,96 @@
+package audioutil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sweep generates a linear sine sweep from f0 to f1 Hz over n samples at rate.
+func sweep(n, rate int, f0, f1 float64) []float32 {
+	duration := float64(n) / float64(rate)
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(sweepAt(float64(i)/float64(rate), duration, f0, f1))
+	}
+	return samples
+}
+
+func sweepAt(t, duration, f0, f1 float64) float64 {
+	return 0.5 * math.Sin(2*math.Pi*(f0*t+(f1-f0)*t*t/(2*duration)))
+}
+
+// maxError returns the largest difference between out and the sweep sampled at rate,
+// skipping the edges affected by the filter running out of input.
+func maxError(out []float32, rate int, duration, f0, f1 float64) float64 {
+	margin := rate / 50
+	var maxErr float64
+	for i := margin; i < len(out)-margin; i++ {
+		expected := sweepAt(float64(i)/float64(rate), duration, f0, f1)
+		maxErr = max(maxErr, math.Abs(float64(out[i])-expected))
+	}
+	return maxErr
+}
+
+func rms(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestResample(t *testing.T) {
+	tcs := []struct {
+		name     string
+		fromRate int
+		toRate   int
+		f1       float64
+	}{
+		{name: "44.1k to 16k", fromRate: 44100, toRate: 16000, f1: 6000},
+		{name: "48k to 16k", fromRate: 48000, toRate: 16000, f1: 6000},
+		{name: "48k to 8k", fromRate: 48000, toRate: 8000, f1: 3000},
+		{name: "8k to 16k", fromRate: 8000, toRate: 16000, f1: 3000},
+		{name: "22.05k to 16k", fromRate: 22050, toRate: 16000, f1: 6000},
+		{name: "16k to 8k", fromRate: 16000, toRate: 8000, f1: 3000},
+		{name: "large ratio", fromRate: 44100, toRate: 16001, f1: 6000},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			n := tc.fromRate / 2
+			duration := float64(n) / float64(tc.fromRate)
+			out := Resample(sweep(n, tc.fromRate, 100, tc.f1), tc.fromRate, tc.toRate)
+			require.Len(t, out, int(math.Ceil(float64(n)*float64(tc.toRate)/float64(tc.fromRate))))
+			require.Less(t, maxError(out, tc.toRate, duration, 100, tc.f1), 0.01)
+		})
+	}
+
+	t.Run("anti-aliasing", func(t *testing.T) {
+		// A tone above the target Nyquist frequency should be filtered out.
+		out := Resample(sweep(48000, 48000, 10000, 10000), 48000, 16000)
+		require.Less(t, rms(out[320:len(out)-320]), 0.001)
+	})
+
+	t.Run("same rate", func(t *testing.T) {
+		in := sweep(1000, 16000, 100, 1000)
+		out := Resample(in, 16000, 16000)
+		require.Equal(t, in, out)
+		out[0] = 42
+		require.NotEqual(t, in[0], out[0])
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, Resample(nil, 44100, 16000))
+	})
+
+	t.Run("invalid rates", func(t *testing.T) {
+		require.Panics(t, func() {
+			Resample(make([]float32, 10), 0, 16000)
+		})
+		require.Panics(t, func() {
+			Resample(make([]float32, 10), 16000, -1)
+		})
+	})
+}
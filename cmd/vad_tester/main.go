@@ -1,28 +1,29 @@
 package main
 
 import (
-	"encoding/binary"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
-	"math"
 	"os"
 	"time"
 
-	"github.com/streamer45/silero-vad-go/speech"
+	"github.com/skypro1111/silero-vad-go/speech"
 )
 
 func main() {
 	// Command line parameters
 	modelPath := flag.String("model", "testfiles/silero_vad.onnx", "Path to Silero VAD model")
-	audioPath := flag.String("audio", "testfiles/samples.pcm", "Path to PCM audio file")
+	audioPath := flag.String("audio", "testfiles/samples.pcm", "Path to PCM audio file, or - to stream it from stdin")
 	sampleRate := flag.Int("sr", 16000, "Sample rate (8000 or 16000)")
+	sampleFormat := flag.String("format", "float32le", "Sample format (int16le, int16be, float32le or float32be)")
 	threshold := flag.Float64("threshold", 0.5, "Speech detection probability threshold")
 	negThreshold := flag.Float64("neg-threshold", 0.0, "Silence detection probability threshold (0 = auto)")
 	minSilence := flag.Int("min-silence", 500, "Minimum silence duration (ms)")
 	minSpeech := flag.Int("min-speech", 250, "Minimum speech duration (ms)")
 	speechPad := flag.Int("speech-pad", 30, "Speech segments padding (ms)")
 	verbose := flag.Bool("verbose", false, "Verbose output")
+	progress := flag.Bool("progress", false, "Show detection progress")
 	flag.Parse()
 
 	// Configure logging
@@ -35,14 +36,11 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
-	// Load audio file
-	slog.Info("Loading audio file", "path", *audioPath)
-	samples, err := readPCMFile(*audioPath)
+	format, err := speech.ParseSampleFormat(*sampleFormat)
 	if err != nil {
-		slog.Error("Failed to load audio file", "error", err)
+		slog.Error("Invalid sample format", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Audio file loaded", "samples", len(samples), "duration", fmt.Sprintf("%.2f sec", float64(len(samples))/float64(*sampleRate)))
 
 	// Create detector configuration
 	cfg := speech.DetectorConfig{
@@ -56,6 +54,32 @@ func main() {
 		LogLevel:             speech.LogLevelError,
 	}
 
+	if *audioPath == "-" {
+		if err := detectStdin(cfg, format); err != nil {
+			slog.Error("Speech detection failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load audio file
+	slog.Info("Loading audio file", "path", *audioPath, "format", format)
+	samples, err := readPCMFile(*audioPath, format)
+	if err != nil {
+		slog.Error("Failed to load audio file", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Audio file loaded", "samples", len(samples), "duration", fmt.Sprintf("%.2f sec", float64(len(samples))/float64(*sampleRate)))
+
+	if *progress {
+		cfg.OnProgress = func(processedSamples, totalSamples int) {
+			fmt.Fprintf(os.Stderr, "\rProgress: %3.0f%%", float64(processedSamples)/float64(totalSamples)*100)
+			if processedSamples == totalSamples {
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+	}
+
 	// Create detector
 	slog.Info("Creating speech detector")
 	startTime := time.Now()
@@ -68,59 +92,105 @@ func main() {
 	slog.Info("Detector created", "elapsed", time.Since(startTime))
 
 	// Detect speech
-	slog.Info("Starting speech detection", 
-		"threshold", cfg.Threshold, 
+	slog.Info("Starting speech detection",
+		"threshold", cfg.Threshold,
 		"negThreshold", cfg.NegativeThreshold,
 		"minSilence", cfg.MinSilenceDurationMs,
 		"minSpeech", cfg.MinSpeechDurationMs,
 		"speechPad", cfg.SpeechPadMs)
-	
+
 	startTime = time.Now()
-	segments, err := detector.Detect(samples)
+	segments, summary, err := detector.DetectWithSummary(samples)
 	if err != nil {
 		slog.Error("Speech detection failed", "error", err)
 		os.Exit(1)
 	}
-	
+
 	// Output results
 	duration := time.Since(startTime)
-	slog.Info("Speech detection completed", 
-		"segments", len(segments), 
+	slog.Info("Speech detection completed",
+		"segments", len(segments),
 		"elapsed", duration,
-		"rtf", duration.Seconds()/(float64(len(samples))/float64(*sampleRate)))
+		"rtf", duration.Seconds()/summary.AudioDuration)
 
 	fmt.Println("\nDetected speech segments:")
 	fmt.Println("------------------------")
-	totalSpeechDuration := 0.0
 	for i, segment := range segments {
 		segmentDuration := segment.SpeechEndAt - segment.SpeechStartAt
-		if segment.SpeechEndAt > 0 {
-			totalSpeechDuration += segmentDuration
+		if !segment.Unfinished {
 			fmt.Printf("%d. %.2f - %.2f (%.2f sec)\n", i+1, segment.SpeechStartAt, segment.SpeechEndAt, segmentDuration)
 		} else {
 			fmt.Printf("%d. %.2f - [unfinished segment]\n", i+1, segment.SpeechStartAt)
 		}
 	}
-	
-	audioDuration := float64(len(samples)) / float64(*sampleRate)
-	fmt.Printf("\nTotal audio duration: %.2f sec\n", audioDuration)
-	fmt.Printf("Total speech duration: %.2f sec (%.1f%%)\n", 
-		totalSpeechDuration, 
-		(totalSpeechDuration/audioDuration)*100)
+
+	fmt.Printf("\nTotal audio duration: %.2f sec\n", summary.AudioDuration)
+	fmt.Printf("Total speech duration: %.2f sec (%.1f%%)\n",
+		summary.SpeechDuration,
+		summary.SpeechRatio*100)
+	if summary.SegmentCount > 0 {
+		fmt.Printf("Time to first speech: %.2f sec\n", summary.TimeToFirstSpeech)
+	}
 }
 
-// Read PCM file with float32 samples
-func readPCMFile(path string) ([]float32, error) {
+// Read PCM file with samples encoded as format
+func readPCMFile(path string, format speech.SampleFormat) ([]float32, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	samples := make([]float32, 0, len(data)/4)
-	for i := 0; i < len(data); i += 4 {
-		if i+4 <= len(data) {
-			samples = append(samples, math.Float32frombits(binary.LittleEndian.Uint32(data[i:i+4])))
+	// Ignore a trailing partial sample, if any.
+	data = data[:len(data)-len(data)%format.Width()]
+
+	return speech.DecodeSamples(data, format)
+}
+
+// Detect speech over the audio streamed from stdin, encoded as format, printing
+// segments as they are finalized.
+func detectStdin(cfg speech.DetectorConfig, format speech.SampleFormat) error {
+	chunks, err := speech.NewChunkReader(os.Stdin, format, cfg.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	stream, err := speech.NewStreamDetector(cfg, speech.StreamCallbacks{})
+	if err != nil {
+		return err
+	}
+	defer stream.Destroy()
+
+	slog.Info("Streaming speech detection from stdin", "format", format)
+
+	var count int
+	for {
+		chunk, err := chunks.Next()
+		if err == io.EOF {
+			break
 		}
+		if err != nil {
+			return err
+		}
+
+		segments, err := stream.Process(chunk)
+		if err != nil {
+			return err
+		}
+		for _, segment := range segments {
+			count++
+			fmt.Printf("%d. %.2f - %.2f (%.2f sec)\n", count, segment.SpeechStartAt, segment.SpeechEndAt, segment.SpeechEndAt-segment.SpeechStartAt)
+		}
+	}
+
+	segments, err := stream.Flush()
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		count++
+		fmt.Printf("%d. %.2f - [unfinished segment]\n", count, segment.SpeechStartAt)
 	}
-	return samples, nil
-} 
\ No newline at end of file
+
+	slog.Info("Speech detection completed", "segments", count)
+	return nil
+}
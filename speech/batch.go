@@ -0,0 +1,232 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// ProbabilitiesBatch runs inference over several independent inputs at once and
+// returns the speech probability of each window of each input, windows being split as
+// by Detect. For models declaring a dynamic batch dimension, the windows at the same
+// position in every input are stacked into a single tensor, so that processing B
+// inputs takes one inference call per window position rather than B, which amortizes
+// the call overhead. Other models fall back to running inference over each input in
+// turn, producing the same probabilities.
+//
+// The windows of a single input can't be batched, since the model state of each window
+// depends on the previous one. Each input is processed from a fresh state, leaving the
+// detector state untouched, and the probabilities are the raw model outputs:
+// InputScale, HighPassHz, ProbSmoothingWindows and OutputType don't apply.
+func (sd *Detector) ProbabilitiesBatch(inputs [][]float32) ([][]float32, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	for i, pcm := range inputs {
+		if err := sd.checkInputLen(len(pcm)); err != nil {
+			return nil, fmt.Errorf("invalid input %d: %w", i, err)
+		}
+	}
+
+	lanes := make([]batchLane, len(inputs))
+	for i, pcm := range inputs {
+		lanes[i] = batchLane{
+			pcm:   pcm,
+			probs: make([]float32, 0, (len(pcm)-1)/sd.windowSize()),
+		}
+	}
+
+	if sd.batchable {
+		if err := sd.inferLanes(lanes); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range lanes {
+			if err := sd.inferLanes(lanes[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	probs := make([][]float32, len(lanes))
+	for i := range lanes {
+		probs[i] = lanes[i].probs
+	}
+
+	return probs, nil
+}
+
+// batchLane holds the inference state of one of the inputs of a batch.
+type batchLane struct {
+	pcm   []float32
+	state [stateLen]float32
+	ctx   [maxContextLen]float32
+	probs []float32
+}
+
+// inferLanes runs inference over the windows of lanes, all the lanes having a window
+// at a given position being processed by a single call.
+func (sd *Detector) inferLanes(lanes []batchLane) error {
+	windowSize := sd.windowSize()
+	active := make([]*batchLane, 0, len(lanes))
+	for pos := 0; ; pos += windowSize {
+		active = active[:0]
+		for i := range lanes {
+			if pos < len(lanes[i].pcm)-windowSize {
+				active = append(active, &lanes[i])
+			}
+		}
+		if len(active) == 0 {
+			return nil
+		}
+
+		sd.closeMu.Lock()
+		if sd.closed {
+			sd.closeMu.Unlock()
+			return ErrDetectorClosed
+		}
+		sd.inferring.Add(1)
+		sd.closeMu.Unlock()
+
+		sem := acquireInference()
+		err := sd.inferBatch(active, pos)
+		releaseInference(sem)
+		sd.inferring.Done()
+
+		if err != nil {
+			return fmt.Errorf("infer failed: %w", err)
+		}
+	}
+}
+
+// inferBatch runs a single inference call over the windows at pos of lanes, updating
+// their state and appending the resulting probabilities.
+func (sd *Detector) inferBatch(lanes []*batchLane, pos int) error {
+	windowSize := sd.windowSize()
+	batch := len(lanes)
+
+	// As with modelInput, split state models don't take the context.
+	useCtx := !sd.splitState
+	contextLen := sd.contextLen()
+	inputLen := windowSize
+	if useCtx {
+		inputLen += contextLen
+	}
+
+	pcm := make([]float32, 0, batch*inputLen)
+	for _, lane := range lanes {
+		window := lane.pcm[pos : pos+windowSize]
+		if useCtx {
+			pcm = append(pcm, lane.ctx[:contextLen]...)
+		}
+		pcm = append(pcm, window...)
+		copy(lane.ctx[:contextLen], window[windowSize-contextLen:])
+	}
+
+	var pcmBuf []byte
+	pcmData := encodeTensor(sd.cfg.ElementType, pcm, &pcmBuf)
+	pcmDims := []tensorDim{tensorDim(batch), tensorDim(inputLen)}
+	var pcmValue *C.OrtValue
+	status := C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&pcmData[0]), C.size_t(len(pcmData)), &pcmDims[0], C.size_t(len(pcmDims)), sd.cfg.ElementType.ortType(), &pcmValue)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseValue(sd.api, pcmValue)
+
+	// The state of each lane is made of parts (the single state tensor, or the h and
+	// c tensors of split state models) shaped [2, 1, partDim], stacked along the
+	// batch axis into [2, batch, partDim] tensors.
+	parts := 1
+	if sd.splitState {
+		parts = 2
+	}
+	partLen := stateLen / parts
+	partDim := partLen / 2
+	stateDims := []tensorDim{2, tensorDim(batch), tensorDim(partDim)}
+
+	stacked := make([][]float32, parts)
+	stateValues := make([]*C.OrtValue, parts)
+	for p := range stacked {
+		stacked[p] = make([]float32, batch*partLen)
+		for b, lane := range lanes {
+			for l := 0; l < 2; l++ {
+				copy(stacked[p][(l*batch+b)*partDim:][:partDim], lane.state[p*partLen+l*partDim:][:partDim])
+			}
+		}
+
+		var buf []byte
+		data := encodeTensor(sd.cfg.ElementType, stacked[p], &buf)
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&data[0]), C.size_t(len(data)), &stateDims[0], C.size_t(len(stateDims)), sd.cfg.ElementType.ortType(), &stateValues[p])
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+		defer C.OrtApiReleaseValue(sd.api, stateValues[p])
+	}
+
+	// The sample rate is shared by the whole batch.
+	var rateValue *C.OrtValue
+	rateDims := []tensorDim{1}
+	if sd.floatRate {
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&sd.rateFloat[0]), C.size_t(4), &rateDims[0], C.size_t(len(rateDims)), C.ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT, &rateValue)
+	} else {
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&sd.rate[0]), C.size_t(8), &rateDims[0], C.size_t(len(rateDims)), C.ONNX_TENSOR_ELEMENT_DATA_TYPE_INT64, &rateValue)
+	}
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseValue(sd.api, rateValue)
+
+	var inputs []*C.OrtValue
+	if sd.splitState {
+		inputs = []*C.OrtValue{pcmValue, rateValue, stateValues[0], stateValues[1]}
+	} else {
+		inputs = []*C.OrtValue{pcmValue, stateValues[0], rateValue}
+	}
+	outputs := make([]*C.OrtValue, len(sd.outputNames))
+	status = C.OrtApiRun(sd.api, sd.session, nil, &sd.inputNames[0], &inputs[0], C.size_t(len(sd.inputNames)), &sd.outputNames[0], C.size_t(len(sd.outputNames)), &outputs[0])
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to run: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer func() {
+		for _, output := range outputs {
+			C.OrtApiReleaseValue(sd.api, output)
+		}
+	}()
+
+	var prob unsafe.Pointer
+	status = C.OrtApiGetTensorMutableData(sd.api, outputs[0], &prob)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to get tensor data: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	probs := make([]float32, batch)
+	decodeTensor(sd.cfg.ElementType, prob, probs)
+
+	for p, output := range outputs[1:] {
+		var stateN unsafe.Pointer
+		status = C.OrtApiGetTensorMutableData(sd.api, output, &stateN)
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return fmt.Errorf("failed to get tensor data: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+		decodeTensor(sd.cfg.ElementType, stateN, stacked[p])
+		for b, lane := range lanes {
+			for l := 0; l < 2; l++ {
+				copy(lane.state[p*partLen+l*partDim:][:partDim], stacked[p][(l*batch+b)*partDim:][:partDim])
+			}
+		}
+	}
+
+	for b, lane := range lanes {
+		lane.probs = append(lane.probs, probs[b])
+	}
+
+	return nil
+}
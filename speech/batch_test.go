@@ -0,0 +1,60 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbabilitiesBatch(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+	inputs := [][]float32{samples, samples[:30000], samples[10000:]}
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	// Each input gets the probabilities it gets on its own from a fresh state.
+	expected := make([][]float32, len(inputs))
+	for i, pcm := range inputs {
+		require.NoError(t, sd.Reset())
+		_, trace, err := sd.DetectWithTrace(pcm)
+		require.NoError(t, err)
+		for _, decision := range trace {
+			expected[i] = append(expected[i], decision.Probability)
+		}
+		require.Len(t, expected[i], sd.WindowCount(len(pcm)))
+	}
+
+	t.Run("batched", func(t *testing.T) {
+		probs, err := sd.ProbabilitiesBatch(inputs)
+		require.NoError(t, err)
+		require.Equal(t, expected, probs)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		defer func(batchable bool) {
+			sd.batchable = batchable
+		}(sd.batchable)
+		sd.batchable = false
+
+		probs, err := sd.ProbabilitiesBatch(inputs)
+		require.NoError(t, err)
+		require.Equal(t, expected, probs)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := sd.ProbabilitiesBatch([][]float32{samples, nil})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid input 1")
+	})
+}
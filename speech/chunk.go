@@ -0,0 +1,89 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ChunkReader reads raw audio from an io.Reader in chunks of one window of samples,
+// decoded to float32, ready to be fed to a StreamDetector. It takes care of buffering
+// the samples across partial reads, as from pipes and network connections:
+//
+//	chunks, err := speech.NewChunkReader(os.Stdin, speech.SampleFormatInt16LE, 16000)
+//	...
+//	for {
+//		chunk, err := chunks.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//		segments, err := stream.Process(chunk)
+//		...
+//	}
+type ChunkReader struct {
+	r      io.Reader
+	format SampleFormat
+
+	// Buffers reused across calls to Next.
+	data  []byte
+	chunk []float32
+
+	// Whether the end of the input was reached.
+	done bool
+}
+
+// NewChunkReader creates a reader decoding the samples read from r, encoded as format,
+// in chunks of one window of the model at sampleRate.
+func NewChunkReader(r io.Reader, format SampleFormat, sampleRate int) (*ChunkReader, error) {
+	if r == nil {
+		return nil, fmt.Errorf("invalid nil reader")
+	}
+
+	if format.Width() == 0 {
+		return nil, fmt.Errorf("invalid sample format")
+	}
+
+	if !slices.Contains(supportedSampleRates, sampleRate) {
+		return nil, fmt.Errorf("invalid sample rate: valid values are 8000 and 16000")
+	}
+
+	windowSize := windowSizeFor(sampleRate)
+	return &ChunkReader{
+		r:      r,
+		format: format,
+		data:   make([]byte, windowSize*format.Width()),
+		chunk:  make([]float32, 0, windowSize),
+	}, nil
+}
+
+// Next reads the next chunk of samples. All chunks are one window long except the last
+// one, which holds the remaining samples when the input doesn't end on a window
+// boundary, a trailing partial sample being ignored. Once the input is exhausted, Next
+// returns io.EOF. The returned slice is only valid until the next call.
+func (c *ChunkReader) Next() ([]float32, error) {
+	if c.done {
+		return nil, io.EOF
+	}
+
+	n, err := io.ReadFull(c.r, c.data)
+	switch {
+	case errors.Is(err, io.EOF):
+		c.done = true
+		return nil, io.EOF
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		c.done = true
+	case err != nil:
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	width := c.format.Width()
+	n -= n % width
+	if n == 0 {
+		return nil, io.EOF
+	}
+
+	c.chunk = appendSamples(c.chunk[:0], c.data[:n], c.format)
+	return c.chunk, nil
+}
@@ -0,0 +1,89 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkReader(t *testing.T) {
+	// readAll returns the chunks read from r.
+	readAll := func(t *testing.T, r *ChunkReader) [][]float32 {
+		var chunks [][]float32
+		for {
+			chunk, err := r.Next()
+			if err == io.EOF {
+				return chunks
+			}
+			require.NoError(t, err)
+			chunks = append(chunks, append([]float32(nil), chunk...))
+		}
+	}
+
+	samples := make([]float32, 600)
+	for i := range samples {
+		samples[i] = float32(i%100)/100 - 0.5
+	}
+
+	t.Run("float32", func(t *testing.T) {
+		var data []byte
+		for _, s := range samples {
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(s))
+		}
+		// A trailing partial sample, which is ignored.
+		data = append(data, 0x01, 0x02)
+
+		// Reads return a single byte at a time.
+		r, err := NewChunkReader(iotest.OneByteReader(bytes.NewReader(data)), SampleFormatFloat32LE, 16000)
+		require.NoError(t, err)
+
+		chunks := readAll(t, r)
+		require.Len(t, chunks, 2)
+		require.Equal(t, samples[:512], chunks[0])
+		require.Equal(t, samples[512:], chunks[1])
+
+		_, err = r.Next()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("int16", func(t *testing.T) {
+		var data []byte
+		for _, s := range samples[:512] {
+			data = binary.BigEndian.AppendUint16(data, uint16(int16(s*32768)))
+		}
+
+		r, err := NewChunkReader(bytes.NewReader(data), SampleFormatInt16BE, 8000)
+		require.NoError(t, err)
+
+		chunks := readAll(t, r)
+		require.Len(t, chunks, 2)
+		require.InDeltaSlice(t, samples[:256], chunks[0], 1e-4)
+		require.InDeltaSlice(t, samples[256:512], chunks[1], 1e-4)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("boom")
+		r, err := NewChunkReader(iotest.ErrReader(readErr), SampleFormatInt16LE, 16000)
+		require.NoError(t, err)
+
+		_, err = r.Next()
+		require.ErrorIs(t, err, readErr)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewChunkReader(nil, SampleFormatInt16LE, 16000)
+		require.EqualError(t, err, "invalid nil reader")
+
+		_, err = NewChunkReader(bytes.NewReader(nil), SampleFormat(42), 16000)
+		require.EqualError(t, err, "invalid sample format")
+
+		_, err = NewChunkReader(bytes.NewReader(nil), SampleFormatInt16LE, 44100)
+		require.EqualError(t, err, "invalid sample rate: valid values are 8000 and 16000")
+	})
+}
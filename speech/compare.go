@@ -0,0 +1,109 @@
+package speech
+
+import "math"
+
+// SegmentMatch pairs a reference segment with the detected segment matching it.
+type SegmentMatch struct {
+	Reference Segment
+	Detected  Segment
+}
+
+// SegmentDiff is the result of comparing detected segments against reference ones,
+// as returned by CompareSegments.
+type SegmentDiff struct {
+	// The reference segments matched by a detected segment, in reference order.
+	Matched []SegmentMatch
+	// The reference segments no detected segment matches, i.e. the false negatives.
+	Missed []Segment
+	// The detected segments matching no reference segment, i.e. the false positives.
+	Extra []Segment
+}
+
+// Precision returns the fraction of the detected segments that match a reference
+// segment, or 1 if no segment was detected.
+func (d SegmentDiff) Precision() float64 {
+	if len(d.Matched)+len(d.Extra) == 0 {
+		return 1
+	}
+	return float64(len(d.Matched)) / float64(len(d.Matched)+len(d.Extra))
+}
+
+// Recall returns the fraction of the reference segments matched by a detected
+// segment, or 1 if there are no reference segments.
+func (d SegmentDiff) Recall() float64 {
+	if len(d.Matched)+len(d.Missed) == 0 {
+		return 1
+	}
+	return float64(len(d.Matched)) / float64(len(d.Matched)+len(d.Missed))
+}
+
+// F1 returns the harmonic mean of Precision and Recall.
+func (d SegmentDiff) F1() float64 {
+	precision, recall := d.Precision(), d.Recall()
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
+
+// CompareSegments compares the detected segments against the reference ones, e.g. a
+// ground truth annotation, for evaluation purposes. A detected segment matches a
+// reference segment when both their starts and their ends are within tolSec seconds
+// of each other. Unfinished segments only match unfinished ones, on their start. Each
+// segment is matched at most once, reference segments being paired in order with the
+// closest detected segment left.
+func CompareSegments(reference, detected []Segment, tolSec float64) SegmentDiff {
+	var diff SegmentDiff
+	used := make([]bool, len(detected))
+
+	for _, ref := range reference {
+		best := -1
+		bestDist := math.Inf(1)
+		for i, det := range detected {
+			if used[i] {
+				continue
+			}
+			if dist, ok := segmentDistance(ref, det, tolSec); ok && dist < bestDist {
+				best = i
+				bestDist = dist
+			}
+		}
+
+		if best < 0 {
+			diff.Missed = append(diff.Missed, ref)
+			continue
+		}
+		used[best] = true
+		diff.Matched = append(diff.Matched, SegmentMatch{Reference: ref, Detected: detected[best]})
+	}
+
+	for i, det := range detected {
+		if !used[i] {
+			diff.Extra = append(diff.Extra, det)
+		}
+	}
+
+	return diff
+}
+
+// segmentDistance returns the sum of the differences between the boundaries of a and
+// b, and whether they match within tolSec.
+func segmentDistance(a, b Segment, tolSec float64) (float64, bool) {
+	if a.Unfinished != b.Unfinished {
+		return 0, false
+	}
+
+	dist := math.Abs(a.SpeechStartAt - b.SpeechStartAt)
+	if dist > tolSec {
+		return 0, false
+	}
+	if !a.Unfinished {
+		endDist := math.Abs(a.SpeechEndAt - b.SpeechEndAt)
+		if endDist > tolSec {
+			return 0, false
+		}
+		dist += endDist
+	}
+
+	return dist, true
+}
@@ -0,0 +1,64 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSegments(t *testing.T) {
+	reference := []Segment{
+		{SpeechStartAt: 1.0, SpeechEndAt: 2.0},
+		{SpeechStartAt: 3.0, SpeechEndAt: 4.0},
+		{SpeechStartAt: 6.0, SpeechEndAt: 7.0},
+		{SpeechStartAt: 9.0, Unfinished: true},
+	}
+
+	detected := []Segment{
+		{SpeechStartAt: 0.95, SpeechEndAt: 2.05},
+		// Within tolerance of the second reference segment, but further than the next.
+		{SpeechStartAt: 3.09, SpeechEndAt: 4.0},
+		{SpeechStartAt: 3.01, SpeechEndAt: 3.98},
+		// Its end is out of tolerance.
+		{SpeechStartAt: 6.0, SpeechEndAt: 7.5},
+		{SpeechStartAt: 9.05, Unfinished: true},
+	}
+
+	t.Run("diff", func(t *testing.T) {
+		diff := CompareSegments(reference, detected, 0.1)
+		require.Equal(t, []SegmentMatch{
+			{Reference: reference[0], Detected: detected[0]},
+			{Reference: reference[1], Detected: detected[2]},
+			{Reference: reference[3], Detected: detected[4]},
+		}, diff.Matched)
+		require.Equal(t, []Segment{reference[2]}, diff.Missed)
+		require.Equal(t, []Segment{detected[1], detected[3]}, diff.Extra)
+
+		require.InDelta(t, 3.0/5, diff.Precision(), 1e-9)
+		require.InDelta(t, 3.0/4, diff.Recall(), 1e-9)
+		require.InDelta(t, 2*0.6*0.75/(0.6+0.75), diff.F1(), 1e-9)
+	})
+
+	t.Run("unfinished", func(t *testing.T) {
+		diff := CompareSegments(
+			[]Segment{{SpeechStartAt: 1.0, Unfinished: true}},
+			[]Segment{{SpeechStartAt: 1.0, SpeechEndAt: 2.0}},
+			0.1)
+		require.Empty(t, diff.Matched)
+		require.Len(t, diff.Missed, 1)
+		require.Len(t, diff.Extra, 1)
+		require.Zero(t, diff.F1())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		diff := CompareSegments(nil, nil, 0.1)
+		require.Equal(t, 1.0, diff.Precision())
+		require.Equal(t, 1.0, diff.Recall())
+		require.Equal(t, 1.0, diff.F1())
+
+		diff = CompareSegments(reference, nil, 0.1)
+		require.Equal(t, reference, diff.Missed)
+		require.Equal(t, 1.0, diff.Precision())
+		require.Zero(t, diff.Recall())
+	})
+}
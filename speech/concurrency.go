@@ -0,0 +1,52 @@
+package speech
+
+import "sync"
+
+// inferenceLimit holds the semaphore limiting the inference calls running at once
+// across all the detectors, nil when unlimited.
+var inferenceLimit struct {
+	mu  sync.Mutex
+	sem chan struct{}
+}
+
+// SetMaxConcurrentInferences limits the number of inference calls running at once,
+// across all the detectors of the process, to n. Calls past the limit wait for a
+// running one to complete. A value of zero or less removes the limit, which is the
+// default. Calls already running or waiting keep the limit in effect when they began.
+//
+// Detectors created with NewDetector run inference on a single thread, as they set
+// ONNX Runtime's intra and inter op thread counts to 1, so that n also bounds the
+// number of threads busy running inference. Sessions passed to NewDetectorFromSession
+// may be configured to use more threads each, in which case the total is up to n times
+// their thread count. Limiting inference is useful in servers handling many streams,
+// to avoid oversubscribing the CPU.
+func SetMaxConcurrentInferences(n int) {
+	inferenceLimit.mu.Lock()
+	defer inferenceLimit.mu.Unlock()
+
+	if n <= 0 {
+		inferenceLimit.sem = nil
+		return
+	}
+	inferenceLimit.sem = make(chan struct{}, n)
+}
+
+// acquireInference waits for an inference call to be allowed to run, returning the
+// semaphore to pass to releaseInference once done.
+func acquireInference() chan struct{} {
+	inferenceLimit.mu.Lock()
+	sem := inferenceLimit.sem
+	inferenceLimit.mu.Unlock()
+
+	if sem != nil {
+		sem <- struct{}{}
+	}
+	return sem
+}
+
+// releaseInference signals the end of an inference call allowed by acquireInference.
+func releaseInference(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
@@ -0,0 +1,94 @@
+package speech
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMaxConcurrentInferences(t *testing.T) {
+	defer SetMaxConcurrentInferences(0)
+
+	t.Run("limit", func(t *testing.T) {
+		SetMaxConcurrentInferences(2)
+
+		first := acquireInference()
+		second := acquireInference()
+
+		acquired := make(chan chan struct{})
+		go func() {
+			acquired <- acquireInference()
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("acquired past the limit")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		releaseInference(first)
+		third := <-acquired
+		releaseInference(second)
+		releaseInference(third)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		SetMaxConcurrentInferences(0)
+
+		sem := acquireInference()
+		require.Nil(t, sem)
+		releaseInference(sem)
+	})
+
+	t.Run("detection", func(t *testing.T) {
+		SetMaxConcurrentInferences(2)
+
+		samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+		// Hold every slot, so that detection can't run any inference until they're
+		// released.
+		held := []chan struct{}{acquireInference(), acquireInference()}
+
+		errs := make(chan error, 4)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				sd, err := NewDetector(DetectorConfig{
+					ModelPath:  "../testfiles/silero_vad.onnx",
+					SampleRate: 16000,
+					Threshold:  0.5,
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				_, err = sd.Detect(samples)
+				errs <- errors.Join(err, sd.Destroy())
+			}()
+		}
+
+		select {
+		case err := <-errs:
+			t.Fatalf("detection completed past the limit: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		for _, sem := range held {
+			releaseInference(sem)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		require.Empty(t, inferenceLimit.sem)
+	})
+}
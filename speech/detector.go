@@ -6,14 +6,41 @@ package speech
 import "C"
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"os"
+	"runtime/cgo"
+	"slices"
+	"sync"
+	"time"
 	"unsafe"
+
+	"github.com/skypro1111/silero-vad-go/audioutil"
 )
 
 const (
-	stateLen   = 2 * 1 * 128
-	contextLen = 64
+	stateLen = 2 * 1 * 128
+	// The number of samples of context at 16kHz, the most of the supported rates.
+	maxContextLen = 64
+
+	// Inputs shorter than this are padded when PadShortInput is set.
+	shortInputMaxMs = 1000
+	// The number of silent windows added on each side of a short input.
+	shortInputPadWindows = 4
+
+	// Input magnitudes above this strongly suggest samples that were not normalized.
+	maxExpectedMagnitude = 4
+
+	// The number of windows between calls to OnProgress.
+	progressWindows = 100
+
+	// The version of the C bridge (ort_bridge.h) the package is written against.
+	bridgeVersion = 6
+
+	// The gap between Threshold and the NegativeThreshold derived from it.
+	negativeThresholdGap = 0.15
 )
 
 type LogLevel int
@@ -43,6 +70,35 @@ const (
 	LogLevelFatal
 )
 
+// MidRangePolicy sets how windows with a speech probability between NegativeThreshold
+// and Threshold are handled during a speech segment. Such windows never start a segment.
+type MidRangePolicy int
+
+const (
+	// Mid-range windows neither extend nor end the segment: they don't cancel a pending
+	// end of the segment, nor start one. This is the default.
+	MidRangePolicyHold MidRangePolicy = iota + 1
+	// Mid-range windows are handled as silence, so that a sustained mid-range
+	// probability eventually ends the segment.
+	MidRangePolicyTreatAsSilence
+	// Mid-range windows are handled as speech, cancelling a pending end of the segment.
+	MidRangePolicyTreatAsSpeech
+)
+
+// OutputType sets the form of the per-window model output reported by DetectDetailed
+// and DetectDetailedAppend. The thresholds always apply to probabilities.
+type OutputType int
+
+const (
+	// The speech probability, in the [0, 1] range, as output by the model. This is the
+	// default.
+	OutputTypeProbability OutputType = iota + 1
+	// The logit of the speech probability p, that is the pre-sigmoid value
+	// ln(p / (1 - p)), with p clamped to [1e-7, 1 - 1e-7] to keep it finite. Useful
+	// for custom calibration, e.g. Platt scaling.
+	OutputTypeLogit
+)
+
 type DetectorConfig struct {
 	// The path to the ONNX Silero VAD model file to load.
 	ModelPath string
@@ -60,6 +116,194 @@ type DetectorConfig struct {
 	SpeechPadMs int
 	// The loglevel for the onnx environment, by default it is set to LogLevelWarn.
 	LogLevel LogLevel
+	// Whether to use an ONNX Runtime environment shared by all the detectors created with
+	// this option, rather than one per detector. The shared environment is created with
+	// the LogLevel of the first of them and released once all of them are destroyed.
+	UseSharedEnv bool
+	// Optional predicate filtering the ONNX Runtime log messages, called with their
+	// severity ("verbose", "info", "warning", "error" or "fatal") and text. When set,
+	// the messages passing LogLevel are routed to slog rather than written to stderr by
+	// ONNX Runtime, and those it returns false for are dropped, e.g. to suppress known
+	// benign warnings. It may be called from ONNX Runtime threads. It isn't supported
+	// along with UseSharedEnv.
+	LogFilter func(severity, message string) bool
+	// Whether to pad inputs shorter than one second with leading and trailing silence
+	// so the model has some context to stabilize before the actual audio. Improves recall
+	// on very short utterances such as single-word commands.
+	PadShortInput bool
+	// The scale factor applied to the input samples before inference. The model expects
+	// samples normalized in the [-1, 1] range so, as an example, int16 samples converted
+	// to float32 as they are should use 1/32768. Defaults to 1.0.
+	InputScale float32
+	// Whether to treat windows for which inference fails as silence and continue detection
+	// rather than failing. The number of such windows is reported through Stats.
+	SkipErrorWindows bool
+	// The offset in seconds added to all the emitted timestamps. Useful to stitch together
+	// the results of processing a longer recording in pieces. Unfinished segments keep a
+	// zero SpeechEndAt.
+	StartOffsetSec float64
+	// The minimum number of windows of audio an input should contain for the model to
+	// have enough context to produce meaningful output. Shorter inputs are rejected.
+	// Defaults to 1.
+	MinWindowsForContext int
+	// The number of consecutive windows with a speech probability at or above the
+	// threshold needed to start a speech segment, which then starts at the first of
+	// them. Higher values reduce false positives caused by impulsive noise. Defaults to 1.
+	TriggerWindows int
+	// The maximum number of windows to move the start of segments back by, over the
+	// windows preceding the trigger whose speech probability was rising towards it, so
+	// that soft onsets such as the first phoneme of a word aren't clipped. It applies
+	// before SpeechPadMs, and takes precedence over RefineBoundaries for the start of
+	// segments it moves. Disabled by default.
+	OnsetBackoffWindows int
+	// The number of windows over which speech probabilities are averaged before being
+	// compared to the thresholds. Smoothing reduces the fragmentation of segments caused
+	// by noisy probabilities. Defaults to 1, meaning no smoothing.
+	ProbSmoothingWindows int
+	// Optional overrides for the names of the model input tensors, for custom exported
+	// models. In order: audio samples, state and sample rate. Defaults to "input", "state"
+	// and "sr". Models taking their state as separate h and c tensors, like the Silero
+	// VAD v4 ones, are detected from their inputs, in which case the state names are
+	// fixed and the state overrides are ignored.
+	InputNames []string
+	// Optional overrides for the names of the model output tensors, for custom exported
+	// models. In order: speech probability and updated state. Defaults to "output" and
+	// "stateN".
+	OutputNames []string
+	// Whether to measure the duration of each inference call. The measurements are
+	// summarized by LatencyStats.
+	CollectLatency bool
+	// The element type of the model input and output tensors. Defaults to
+	// TensorElementTypeFloat32.
+	ElementType TensorElementType
+	// Whether SetThreshold should also update NegativeThreshold, keeping the default gap
+	// between them, when NegativeThreshold was derived from Threshold rather than set
+	// explicitly (either in the config or through SetNegativeThreshold).
+	TrackNegativeThreshold bool
+	// Whether HasSpeech requires speech to last at least MinSpeechDurationMs, rather than
+	// a single window, before reporting it.
+	HasSpeechMinDuration bool
+	// Whether to refine segment boundaries past the window granularity by interpolating
+	// the point at which the speech probability crosses the thresholds between two
+	// consecutive windows.
+	RefineBoundaries bool
+	// Whether Detect and its variants should estimate the signal-to-noise ratio of the
+	// segments, reported as Segment.SNR. Not supported by StreamDetector.
+	EstimateSNR bool
+	// Whether Detect and its variants should end the segment in progress at the end of
+	// the input when silence was detected but didn't last MinSilenceDurationMs yet. The
+	// segment then ends where the silence began, rather than being left unfinished, and
+	// the next input is handled as the start of new audio.
+	CloseOpenSegments bool
+	// Whether DetectConcat should reset the detection state at the start of each file,
+	// rather than carrying it over.
+	ResetBetweenFiles bool
+	// The cutoff frequency in Hz of a high-pass filter applied to the samples before
+	// inference, removing low frequency noise such as rumble, e.g. 80. Disabled by
+	// default.
+	HighPassHz float32
+	// An optional function called periodically while Detect and its variants run, with
+	// the number of samples of the input processed so far and the total. It's called
+	// every 100 windows and, unless detection fails, once more when done, with
+	// processedSamples equal to totalSamples.
+	OnProgress func(processedSamples, totalSamples int)
+	// How windows with a speech probability between NegativeThreshold and Threshold are
+	// handled during a speech segment. Defaults to MidRangePolicyHold.
+	MidRangePolicy MidRangePolicy
+	// Whether to keep the padded start of segments when it's negative, that is when
+	// SpeechPadMs extends them before the start of the audio, rather than clamping it at
+	// zero. Useful when stitching chunks together, a negative start meaning the segment
+	// extends into the previous chunk. StartOffsetSec still applies on top of it, while
+	// starts remain clamped to the beginning of inputs padded because of PadShortInput.
+	AllowNegativeStart bool
+	// The duration in milliseconds of the frames segment boundaries are aligned to, e.g.
+	// 10 to match the frames of an ASR feature extractor. By default segments are
+	// extended to whole frames, their start being rounded down and their end up, see
+	// FrameAlignNearest. Disabled by default.
+	FrameAlignMs int
+	// Whether to round segment boundaries to the nearest frame when FrameAlignMs is set,
+	// rather than extending segments to whole frames.
+	FrameAlignNearest bool
+	// An optional function called with each finished segment before it's returned, as
+	// last processing step, to modify or drop it: the returned segment replaces it unless
+	// false is returned, in which case it's dropped. Unfinished segments aren't passed to
+	// it, and SNR and Envelope aren't updated for modified boundaries. Applies to
+	// StreamDetector as well, before OnSpeechEnd is called.
+	SegmentFilter func(Segment) (Segment, bool)
+	// The number of bytes of header to skip at the start of raw PCM files read by
+	// DetectFile and DetectConcat, before the samples. It doesn't apply to WAV and AIFF
+	// files, which are parsed.
+	HeaderBytes int
+	// Whether DetectFile and DetectConcat resample WAV and AIFF files whose sample rate
+	// doesn't match SampleRate, with audioutil.Resample, rather than failing. By default
+	// such files are rejected, since detecting over them as is would produce wrong
+	// timestamps.
+	ResampleFiles bool
+	// Whether Detect and its variants should trim segments to their voiced windows: a
+	// segment then starts with the first window with a speech probability at or above the
+	// threshold and ends with the last one, without SpeechPadMs padding, dropping the
+	// trailing silence that ended it. MinSpeechDurationMs applies to the trimmed
+	// segments. Not supported by StreamDetector.
+	TightBoundaries bool
+	// The type of the allocator of the memory info the tensors are created with, which
+	// has no effect on memory usage, see AllocatorType. Defaults to AllocatorTypeArena.
+	AllocatorType AllocatorType
+	// The memory type of the memory info the tensors are created with. Defaults to
+	// MemTypeDefault.
+	MemType MemType
+	// The maximum memory in bytes the ONNX Runtime CPU arena can grow to, to bound the
+	// memory usage of the session, e.g. in memory constrained containers. The arena is
+	// then registered on the environment of the detector and used by its session, which
+	// isn't supported along with UseSharedEnv. Defaults to no limit.
+	ArenaMaxMemory int
+	// The size in bytes of the first chunk the ONNX Runtime CPU arena allocates, set up
+	// as for ArenaMaxMemory. Defaults to the ONNX Runtime default.
+	ArenaInitialChunkSize int
+	// The resolution in milliseconds of the RMS envelope Detect and its variants compute
+	// over the audio of each segment, reported as Segment.Envelope, e.g. 10 for 100 values
+	// per second of speech. Disabled by default. Not supported by StreamDetector.
+	EnvelopeResolutionMs int
+	// The path of the file ONNX Runtime should save the model to once optimized, so it
+	// can be inspected or loaded as ModelPath later on to skip optimizing it again. By
+	// default the optimized model is kept in memory only and nothing is written to disk,
+	// which is required on read-only filesystems.
+	OptimizedModelFilePath string
+	// The form of the per-window output reported by DetectDetailed and
+	// DetectDetailedAppend. Defaults to OutputTypeProbability.
+	OutputType OutputType
+	// The duration in milliseconds at the start of the audio within which speech is
+	// ignored, e.g. to skip an intro jingle or the click of a record button. Segments are
+	// trimmed to start after it, and dropped if they end within it. The audio starts with
+	// the first input processed since the detector was created or reset. Not supported by
+	// StreamDetector.
+	IgnoreFirstMs int
+	// The duration in milliseconds at the end of the input within which speech is
+	// ignored, as for IgnoreFirstMs. Segments are trimmed to end before it, and dropped
+	// if they start within it. Not supported by StreamDetector.
+	IgnoreLastMs int
+	// Whether Detect and its variants should treat the input as a single utterance, e.g.
+	// to trim pre-segmented clips: pauses don't split speech, a single segment spanning
+	// from the start of the first segment to the end of the last one being returned, with
+	// SpeechPadMs padding. Segments shorter than MinSpeechDurationMs are dropped before
+	// merging, so that isolated clicks don't extend the utterance. Not supported by
+	// StreamDetector.
+	SingleUtterance bool
+	// Whether Detect and its variants should keep the last finished segment of the input,
+	// even if shorter than MinSpeechDurationMs, e.g. so that a short "yes" confirming at
+	// the end of a recording isn't dropped. Empty segments are still dropped. Not
+	// supported by StreamDetector, which can't tell which segment is the last one.
+	KeepShortLastSegment bool
+	// The thresholds segments are graded with, as reported by Segment.Quality. Defaults
+	// to DefaultQualityThresholds when left unset.
+	QualityThresholds QualityThresholds
+	// The maximum duration in milliseconds of speech in progress without notification,
+	// for StreamDetector: during a segment, StreamCallbacks.OnSpeechPartial is called with
+	// the segment so far every MaxHoldMs. Disabled by default, in which case segments are
+	// only notified on start and end.
+	MaxHoldMs int
+
+	// Whether NegativeThreshold was derived from Threshold by withDefaults.
+	negativeThresholdDerived bool
 }
 
 func (c DetectorConfig) IsValid() error {
@@ -67,7 +311,26 @@ func (c DetectorConfig) IsValid() error {
 		return fmt.Errorf("invalid ModelPath: should not be empty")
 	}
 
-	if c.SampleRate != 8000 && c.SampleRate != 16000 {
+	return c.validateParams()
+}
+
+// The sample rates supported by the model.
+var supportedSampleRates = []int{8000, 16000}
+
+// SupportedSampleRates returns the sample rates, in Hz, that can be set as SampleRate,
+// in increasing order.
+func SupportedSampleRates() []int {
+	return slices.Clone(supportedSampleRates)
+}
+
+// validateParams validates everything but the model related settings.
+func (c DetectorConfig) validateParams() error {
+	if c.SampleRate == 48000 {
+		// A common rate for captured audio, which the model doesn't support natively.
+		return fmt.Errorf("invalid SampleRate: 48000 is unsupported, resample the audio to 16000 (e.g. with audioutil.Resample) and set SampleRate to 16000")
+	}
+
+	if !slices.Contains(supportedSampleRates, c.SampleRate) {
 		return fmt.Errorf("invalid SampleRate: valid values are 8000 and 16000")
 	}
 
@@ -95,9 +358,154 @@ func (c DetectorConfig) IsValid() error {
 		return fmt.Errorf("invalid SpeechPadMs: should be a positive number")
 	}
 
+	if c.InputScale < 0 {
+		return fmt.Errorf("invalid InputScale: should be a positive number")
+	}
+
+	if c.StartOffsetSec < 0 {
+		return fmt.Errorf("invalid StartOffsetSec: should be a positive number")
+	}
+
+	if c.HighPassHz < 0 {
+		return fmt.Errorf("invalid HighPassHz: should be a positive number")
+	}
+
+	if c.HighPassHz > 0 && c.HighPassHz*2 >= float32(c.SampleRate) {
+		return fmt.Errorf("invalid HighPassHz: should be less than half the SampleRate")
+	}
+
+	if c.EnvelopeResolutionMs < 0 {
+		return fmt.Errorf("invalid EnvelopeResolutionMs: should be a positive number")
+	}
+
+	if c.HeaderBytes < 0 {
+		return fmt.Errorf("invalid HeaderBytes: should be a positive number")
+	}
+
+	if c.ArenaMaxMemory < 0 {
+		return fmt.Errorf("invalid ArenaMaxMemory: should be a positive number")
+	}
+
+	if c.ArenaInitialChunkSize < 0 {
+		return fmt.Errorf("invalid ArenaInitialChunkSize: should be a positive number")
+	}
+
+	if c.UseSharedEnv && (c.ArenaMaxMemory > 0 || c.ArenaInitialChunkSize > 0) {
+		return fmt.Errorf("invalid UseSharedEnv: not supported along with the arena settings")
+	}
+
+	if c.UseSharedEnv && c.LogFilter != nil {
+		return fmt.Errorf("invalid UseSharedEnv: not supported along with LogFilter")
+	}
+
+	if err := c.QualityThresholds.validate(); err != nil {
+		return err
+	}
+
+	if c.MaxHoldMs < 0 {
+		return fmt.Errorf("invalid MaxHoldMs: should be a positive number")
+	}
+
+	if c.IgnoreFirstMs < 0 {
+		return fmt.Errorf("invalid IgnoreFirstMs: should be a positive number")
+	}
+
+	if c.IgnoreLastMs < 0 {
+		return fmt.Errorf("invalid IgnoreLastMs: should be a positive number")
+	}
+
+	if c.FrameAlignMs < 0 {
+		return fmt.Errorf("invalid FrameAlignMs: should be a positive number")
+	}
+
+	if c.MinWindowsForContext < 0 {
+		return fmt.Errorf("invalid MinWindowsForContext: should be a positive number")
+	}
+
+	if c.TriggerWindows < 0 {
+		return fmt.Errorf("invalid TriggerWindows: should be a positive number")
+	}
+
+	if c.OnsetBackoffWindows < 0 {
+		return fmt.Errorf("invalid OnsetBackoffWindows: should be a positive number")
+	}
+
+	if c.ProbSmoothingWindows < 0 {
+		return fmt.Errorf("invalid ProbSmoothingWindows: should be a positive number")
+	}
+
+	if c.ElementType != 0 && c.ElementType != TensorElementTypeFloat32 && c.ElementType != TensorElementTypeFloat16 {
+		return fmt.Errorf("invalid ElementType: valid values are TensorElementTypeFloat32 and TensorElementTypeFloat16")
+	}
+
+	if c.MidRangePolicy < 0 || c.MidRangePolicy > MidRangePolicyTreatAsSpeech {
+		return fmt.Errorf("invalid MidRangePolicy: valid values are MidRangePolicyHold, MidRangePolicyTreatAsSilence and MidRangePolicyTreatAsSpeech")
+	}
+
+	if c.AllocatorType < 0 || c.AllocatorType > AllocatorTypeDevice {
+		return fmt.Errorf("invalid AllocatorType: valid values are AllocatorTypeArena and AllocatorTypeDevice")
+	}
+
+	if c.MemType < 0 || c.MemType > MemTypeCPUOutput {
+		return fmt.Errorf("invalid MemType: valid values are MemTypeDefault, MemTypeCPUInput and MemTypeCPUOutput")
+	}
+
+	if c.OutputType < 0 || c.OutputType > OutputTypeLogit {
+		return fmt.Errorf("invalid OutputType: valid values are OutputTypeProbability and OutputTypeLogit")
+	}
+
+	if err := validateTensorNames(c.InputNames, 3); err != nil {
+		return fmt.Errorf("invalid InputNames: %w", err)
+	}
+
+	if err := validateTensorNames(c.OutputNames, 2); err != nil {
+		return fmt.Errorf("invalid OutputNames: %w", err)
+	}
+
+	return nil
+}
+
+func validateTensorNames(names []string, count int) error {
+	if names == nil {
+		return nil
+	}
+
+	if len(names) != count {
+		return fmt.Errorf("should contain %d names", count)
+	}
+
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("names should not be empty")
+		}
+	}
+
 	return nil
 }
 
+// DetectorStats contains counters collected while running speech detection.
+type DetectorStats struct {
+	// The number of windows processed.
+	Windows int
+	// The number of windows for which inference failed and that were treated
+	// as silence because SkipErrorWindows is set.
+	ErrorWindows int
+}
+
+// Detector runs speech detection using a Silero VAD model.
+//
+// A Detector is not safe for concurrent use, with the exception of Config, ResetConfig,
+// Reconfigure and the setters (SetThreshold, SetNegativeThreshold and
+// SetMinSpeechDurationMs). These can be called from any goroutine, including while
+// detection runs on another one, in which case changes take effect starting from the
+// next window processed, and Close, which can be used to stop detection running on
+// another goroutine.
+//
+// Detection is deterministic: NewDetector configures ONNX Runtime to run inference on
+// a single thread, sequentially, so that processing the same input with the same
+// config, from a new or reset detector, always yields identical results. This holds
+// for sessions passed to NewDetectorFromSession as long as they use a single intra op
+// thread, since parallel kernels may reduce floating point values in varying orders.
 type Detector struct {
 	api         *C.OrtApi
 	env         *C.OrtEnv
@@ -105,50 +513,147 @@ type Detector struct {
 	session     *C.OrtSession
 	memoryInfo  *C.OrtMemoryInfo
 	cStrings    map[string]*C.char
+	ownsSession bool
+	// Whether env is the shared environment, see UseSharedEnv.
+	sharedEnv bool
+	// The handle to LogFilter passed to the env logger, if any.
+	logHandle cgo.Handle
 
 	cfg DetectorConfig
+	// The config as resolved at construction, used to revert runtime changes.
+	initialCfg DetectorConfig
+	// Guards pendingCfg as well as writes to cfg, which is only ever changed by
+	// the goroutine running detection so that it can read cfg without locking.
+	cfgMu sync.Mutex
+	// The config resulting from the runtime changes not applied yet, if any.
+	pendingCfg *DetectorConfig
+
+	// Guards closed, destroyed and additions to inferring.
+	closeMu sync.Mutex
+	// Whether Close or Destroy was called, after which no inference can start.
+	closed bool
+	// Whether Destroy was called, after which the ONNX Runtime resources are released.
+	destroyed bool
+	// Tracks the inference calls in progress, waited for by Close.
+	inferring sync.WaitGroup
 
 	state [stateLen]float32
-	ctx   [contextLen]float32
+	ctx   [maxContextLen]float32
+	// Whether the model takes its state split into separate h and c tensors, as the
+	// Silero VAD v4 models do, rather than a single state tensor.
+	splitState bool
+	// Whether the model takes the sample rate as a float tensor rather than an int64 one.
+	floatRate bool
+	// Whether the model declares dynamic batch dimensions, allowing ProbabilitiesBatch
+	// to run inference over several inputs in a single call.
+	batchable bool
+
+	// Buffers and tensor data reused by every inference call, and kept across Reset,
+	// so that processing a window doesn't allocate them anew.
+	inputBuf    []float32
+	pcmEncBuf   []byte
+	stateEncBuf []byte
+	rate        [1]C.int64_t
+	rateFloat   [1]float32
+	inputNames  []*C.char
+	outputNames []*C.char
+	// The values passed to and returned by the model, up to two for the state.
+	stateValues  [2]*C.OrtValue
+	inputValues  []*C.OrtValue
+	outputValues []*C.OrtValue
 
 	currSample int
 	triggered  bool
-	tempEnd    int
+	// The duration of the audio streamed at a previous sample rate, added to the
+	// timestamps on top of StartOffsetSec, see StreamDetector.SetSampleRate.
+	rateChangeOffsetSec float64
+	tempEnd             int
+	// The interpolated position of tempEnd, when refining boundaries.
+	tempEndRefined float64
+	// The speech probability of the previous window.
+	prevProb float32
+	// The number of consecutive speech windows seen while not triggered, along with the
+	// probabilities of the first of them and of the window preceding it, and the sum and
+	// peak of their probabilities.
+	speechRun          int
+	speechRunFirstProb float32
+	speechRunPrevProb  float32
+	speechRunProbSum   float64
+	speechRunPeak      float32
+	// The position of the first window of the speech run that triggered the latest
+	// segment, before applying the onset backoff and the padding.
+	onsetSample int
+	// The onset positions, as onsetSample, of the segments returned by the latest
+	// detection, used to summarize them.
+	onsets []int
+	// The latest raw speech probabilities, averaged when smoothing.
+	recentProbs []float32
+	// The speech probabilities of the latest windows, when backing off onsets.
+	onsetProbs []float32
+
+	inputScaleWarned bool
+
+	// The buffer holding the samples downmixed by DetectInterleavedInt16.
+	monoBuf []float32
+
+	// The high-pass filter applied to the input, if HighPassHz is set.
+	highPass *biquad
+
+	stats     DetectorStats
+	latencies []time.Duration
 }
 
-func NewDetector(cfg DetectorConfig) (*Detector, error) {
+func NewDetector(cfg DetectorConfig) (_ *Detector, err error) {
 	if err := cfg.IsValid(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	// Set default value for NegativeThreshold if not provided
-	if cfg.NegativeThreshold == 0 {
-		cfg.NegativeThreshold = cfg.Threshold - 0.15
-	}
-
-	// Set default value for MinSpeechDurationMs if not provided
-	if cfg.MinSpeechDurationMs == 0 {
-		cfg.MinSpeechDurationMs = 250 // Default to 250ms
+	if err := checkBridgeVersion(); err != nil {
+		return nil, err
 	}
 
 	sd := Detector{
-		cfg:      cfg,
-		cStrings: map[string]*C.char{},
+		cfg:         cfg.withDefaults(),
+		cStrings:    map[string]*C.char{},
+		ownsSession: true,
 	}
+	sd.initialCfg = sd.cfg
 
 	sd.api = C.OrtGetApi()
 	if sd.api == nil {
 		return nil, fmt.Errorf("failed to get API")
 	}
 
-	sd.cStrings["loggerName"] = C.CString("vad")
-	status := C.OrtApiCreateEnv(sd.api, cfg.LogLevel.OrtLoggingLevel(), sd.cStrings["loggerName"], &sd.env)
-	defer C.OrtApiReleaseStatus(sd.api, status)
-	if status != nil {
-		return nil, fmt.Errorf("failed to create env: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	// Free what was acquired so far if the detector can't be created.
+	defer func() {
+		if err != nil {
+			sd.release()
+		}
+	}()
+
+	if sd.cfg.UseSharedEnv {
+		env, envErr := acquireSharedEnv(sd.api, cfg.LogLevel)
+		if envErr != nil {
+			return nil, envErr
+		}
+		sd.env = env
+		sd.sharedEnv = true
+	} else {
+		sd.cStrings["loggerName"] = C.CString("vad")
+		var status *C.OrtStatus
+		if cfg.LogFilter != nil {
+			sd.logHandle = cgo.NewHandle(cfg.LogFilter)
+			status = C.OrtApiCreateEnvWithCustomLogger(sd.api, C.uintptr_t(sd.logHandle), cfg.LogLevel.OrtLoggingLevel(), sd.cStrings["loggerName"], &sd.env)
+		} else {
+			status = C.OrtApiCreateEnv(sd.api, cfg.LogLevel.OrtLoggingLevel(), sd.cStrings["loggerName"], &sd.env)
+		}
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return nil, fmt.Errorf("failed to create env: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
 	}
 
-	status = C.OrtApiCreateSessionOptions(sd.api, &sd.sessionOpts)
+	status := C.OrtApiCreateSessionOptions(sd.api, &sd.sessionOpts)
 	defer C.OrtApiReleaseStatus(sd.api, status)
 	if status != nil {
 		return nil, fmt.Errorf("failed to create session options: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
@@ -172,6 +677,21 @@ func NewDetector(cfg DetectorConfig) (*Detector, error) {
 		return nil, fmt.Errorf("failed to set session graph optimization level: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
 	}
 
+	if sd.cfg.OptimizedModelFilePath != "" {
+		sd.cStrings["optimizedModelFilePath"] = C.CString(sd.cfg.OptimizedModelFilePath)
+		status = C.OrtApiSetOptimizedModelFilePath(sd.api, sd.sessionOpts, sd.cStrings["optimizedModelFilePath"])
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return nil, fmt.Errorf("failed to set optimized model file path: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+	}
+
+	if sd.cfg.ArenaMaxMemory > 0 || sd.cfg.ArenaInitialChunkSize > 0 {
+		if err := sd.registerArena(); err != nil {
+			return nil, err
+		}
+	}
+
 	sd.cStrings["modelPath"] = C.CString(sd.cfg.ModelPath)
 	status = C.OrtApiCreateSession(sd.api, sd.env, sd.cStrings["modelPath"], sd.sessionOpts, &sd.session)
 	defer C.OrtApiReleaseStatus(sd.api, status)
@@ -179,171 +699,1802 @@ func NewDetector(cfg DetectorConfig) (*Detector, error) {
 		return nil, fmt.Errorf("failed to create session: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
 	}
 
-	status = C.OrtApiCreateCpuMemoryInfo(sd.api, C.OrtArenaAllocator, C.OrtMemTypeDefault, &sd.memoryInfo)
-	defer C.OrtApiReleaseStatus(sd.api, status)
-	if status != nil {
-		return nil, fmt.Errorf("failed to create memory info: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	if err := sd.init(); err != nil {
+		return nil, err
+	}
+
+	return &sd, nil
+}
+
+// NewDetectorFromSession creates a detector running inference through an already
+// created ONNX Runtime session. The session argument must point to a valid OrtSession
+// loaded with a Silero VAD model, e.g. one returned by (*Detector).Session or created
+// by the caller through the ONNX Runtime C API. A single session can be shared by
+// multiple detectors.
+//
+// The detector does not take ownership of the session: Destroy will not release it.
+// The caller is responsible for keeping the session (and its environment) alive until
+// all the detectors using it have been destroyed, and for releasing it afterwards.
+//
+// The ModelPath, LogLevel, UseSharedEnv, LogFilter, OptimizedModelFilePath,
+// ArenaMaxMemory and ArenaInitialChunkSize settings in cfg are ignored.
+func NewDetectorFromSession(session unsafe.Pointer, cfg DetectorConfig) (_ *Detector, err error) {
+	if session == nil {
+		return nil, fmt.Errorf("invalid nil session")
+	}
+
+	if err := cfg.validateParams(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := checkBridgeVersion(); err != nil {
+		return nil, err
+	}
+
+	sd := Detector{
+		cfg:      cfg.withDefaults(),
+		cStrings: map[string]*C.char{},
+		session:  (*C.OrtSession)(session),
 	}
+	sd.initialCfg = sd.cfg
+
+	sd.api = C.OrtGetApi()
+	if sd.api == nil {
+		return nil, fmt.Errorf("failed to get API")
+	}
+
+	defer func() {
+		if err != nil {
+			sd.release()
+		}
+	}()
 
-	sd.cStrings["input"] = C.CString("input")
-	sd.cStrings["sr"] = C.CString("sr")
-	sd.cStrings["state"] = C.CString("state")
-	sd.cStrings["stateN"] = C.CString("stateN")
-	sd.cStrings["output"] = C.CString("output")
+	if err := sd.init(); err != nil {
+		return nil, err
+	}
 
 	return &sd, nil
 }
 
-// Segment contains timing information of a speech segment.
-type Segment struct {
-	// The relative timestamp in seconds of when a speech segment begins.
-	SpeechStartAt float64
-	// The relative timestamp in seconds of when a speech segment ends.
-	SpeechEndAt float64
+// checkBridgeVersion checks that the linked C bridge matches the version the package
+// expects, catching stale bridge objects whose functions may not match their Go usage.
+func checkBridgeVersion() error {
+	if version := int(C.OrtBridgeVersion()); version != bridgeVersion {
+		return fmt.Errorf("bridge version mismatch: linked bridge is version %d, expected %d", version, bridgeVersion)
+	}
+	return nil
 }
 
-func (sd *Detector) Detect(pcm []float32) ([]Segment, error) {
-	if sd == nil {
-		return nil, fmt.Errorf("invalid nil detector")
+// withDefaults returns a copy of the config with defaults applied to the unset values.
+func (c DetectorConfig) withDefaults() DetectorConfig {
+	// Set default value for NegativeThreshold if not provided
+	if c.NegativeThreshold == 0 {
+		c.NegativeThreshold = c.Threshold - negativeThresholdGap
+		c.negativeThresholdDerived = true
 	}
 
-	windowSize := 512
-	if sd.cfg.SampleRate == 8000 {
-		windowSize = 256
+	// Set default value for MinSpeechDurationMs if not provided
+	if c.MinSpeechDurationMs == 0 {
+		c.MinSpeechDurationMs = 250 // Default to 250ms
 	}
 
-	if len(pcm) < windowSize {
-		return nil, fmt.Errorf("not enough samples")
+	if c.InputScale == 0 {
+		c.InputScale = 1
 	}
 
-	slog.Debug("starting speech detection", slog.Int("samplesLen", len(pcm)))
+	if c.MinWindowsForContext == 0 {
+		c.MinWindowsForContext = 1
+	}
 
-	minSilenceSamples := sd.cfg.MinSilenceDurationMs * sd.cfg.SampleRate / 1000
-	speechPadSamples := sd.cfg.SpeechPadMs * sd.cfg.SampleRate / 1000
-	minSpeechSamples := sd.cfg.MinSpeechDurationMs * sd.cfg.SampleRate / 1000
+	if c.TriggerWindows == 0 {
+		c.TriggerWindows = 1
+	}
 
-	var segments []Segment
-	for i := 0; i < len(pcm)-windowSize; i += windowSize {
-		speechProb, err := sd.infer(pcm[i : i+windowSize])
-		if err != nil {
-			return nil, fmt.Errorf("infer failed: %w", err)
-		}
+	if c.ProbSmoothingWindows == 0 {
+		c.ProbSmoothingWindows = 1
+	}
 
-		sd.currSample += windowSize
+	if c.ElementType == 0 {
+		c.ElementType = TensorElementTypeFloat32
+	}
 
-		if speechProb >= sd.cfg.Threshold && sd.tempEnd != 0 {
-			sd.tempEnd = 0
-		}
+	if c.MidRangePolicy == 0 {
+		c.MidRangePolicy = MidRangePolicyHold
+	}
 
-		if speechProb >= sd.cfg.Threshold && !sd.triggered {
-			sd.triggered = true
-			speechStartAt := (float64(sd.currSample-windowSize-speechPadSamples) / float64(sd.cfg.SampleRate))
+	if c.OutputType == 0 {
+		c.OutputType = OutputTypeProbability
+	}
 
-			// We clamp at zero since due to padding the starting position could be negative.
-			if speechStartAt < 0 {
-				speechStartAt = 0
-			}
+	if c.AllocatorType == 0 {
+		c.AllocatorType = AllocatorTypeArena
+	}
 
-			slog.Debug("speech start", slog.Float64("startAt", speechStartAt))
-			segments = append(segments, Segment{
-				SpeechStartAt: speechStartAt,
-			})
-		}
+	if c.MemType == 0 {
+		c.MemType = MemTypeDefault
+	}
 
-		if speechProb < sd.cfg.NegativeThreshold && sd.triggered {
-			if sd.tempEnd == 0 {
-				sd.tempEnd = sd.currSample
-			}
+	if c.QualityThresholds == (QualityThresholds{}) {
+		c.QualityThresholds = DefaultQualityThresholds
+	}
 
-			// Not enough silence yet to split, we continue.
-			if sd.currSample-sd.tempEnd < minSilenceSamples {
-				continue
-			}
+	if c.InputNames == nil {
+		c.InputNames = []string{"input", "state", "sr"}
+	}
 
-			speechEndAt := (float64(sd.tempEnd+speechPadSamples) / float64(sd.cfg.SampleRate))
-			sd.tempEnd = 0
-			sd.triggered = false
-			slog.Debug("speech end", slog.Float64("endAt", speechEndAt))
+	if c.OutputNames == nil {
+		c.OutputNames = []string{"output", "stateN"}
+	}
 
-			if len(segments) < 1 {
-				return nil, fmt.Errorf("unexpected speech end")
-			}
+	return c
+}
 
-			segments[len(segments)-1].SpeechEndAt = speechEndAt
-		}
+// init sets up the resources needed to run inference on top of the session.
+func (sd *Detector) init() error {
+	status := C.OrtApiCreateCpuMemoryInfo(sd.api, sd.cfg.AllocatorType.ortType(), sd.cfg.MemType.ortType(), &sd.memoryInfo)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create memory info: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
 	}
 
-	slog.Debug("speech detection done", slog.Int("segmentsLen", len(segments)))
+	sd.cStrings["input"] = C.CString(sd.cfg.InputNames[0])
+	sd.cStrings["state"] = C.CString(sd.cfg.InputNames[1])
+	sd.cStrings["sr"] = C.CString(sd.cfg.InputNames[2])
+	sd.cStrings["output"] = C.CString(sd.cfg.OutputNames[0])
+	sd.cStrings["stateN"] = C.CString(sd.cfg.OutputNames[1])
 
-	// Filter out segments that are too short
-	if sd.cfg.MinSpeechDurationMs > 0 {
-		var filteredSegments []Segment
-		for _, segment := range segments {
-			// Skip segments that don't have an end time yet
-			if segment.SpeechEndAt == 0 {
-				filteredSegments = append(filteredSegments, segment)
-				continue
+	inputNames, err := sessionInputNames(sd.api, sd.session)
+	if err != nil {
+		return err
+	}
+	sd.splitState = hasSplitState(inputNames)
+
+	sd.batchable = true
+	for i, name := range inputNames {
+		switch name {
+		case sd.cfg.InputNames[2]:
+			// The sample rate is passed as int64 unless the model declares a float input.
+			srType, err := sessionInputElementType(sd.api, sd.session, i)
+			if err != nil {
+				return err
 			}
-
-			durationSamples := (segment.SpeechEndAt - segment.SpeechStartAt) * float64(sd.cfg.SampleRate)
-			if durationSamples >= float64(minSpeechSamples) {
-				filteredSegments = append(filteredSegments, segment)
-			} else {
-				slog.Debug("filtered out short speech segment", 
-					slog.Float64("startAt", segment.SpeechStartAt),
-					slog.Float64("endAt", segment.SpeechEndAt),
-					slog.Float64("duration", segment.SpeechEndAt-segment.SpeechStartAt),
-					slog.Int("minDuration", sd.cfg.MinSpeechDurationMs))
+			if srType == C.ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT {
+				slog.Debug("model takes the sample rate as float")
+				sd.floatRate = true
+			}
+		case sd.cfg.InputNames[0], sd.cfg.InputNames[1], "h", "c":
+			// Audio is batched along the first axis, state along the second one.
+			axis := 1
+			if name == sd.cfg.InputNames[0] {
+				axis = 0
+			}
+			shape, err := sessionInputShape(sd.api, sd.session, i)
+			if err != nil {
+				return err
+			}
+			if err := checkBatchDim(name, shape, axis); err != nil {
+				return err
+			}
+			if axis >= len(shape) || shape[axis] > 0 {
+				sd.batchable = false
 			}
 		}
-		segments = filteredSegments
+	}
+	if !sd.batchable {
+		slog.Debug("model has a fixed batch dimension, batches are run one input at a time")
 	}
 
-	return segments, nil
+	if sd.splitState {
+		slog.Debug("model takes split state tensors")
+		sd.cStrings["h"] = C.CString("h")
+		sd.cStrings["c"] = C.CString("c")
+		sd.cStrings["hn"] = C.CString("hn")
+		sd.cStrings["cn"] = C.CString("cn")
+		sd.inputNames = []*C.char{sd.cStrings["input"], sd.cStrings["sr"], sd.cStrings["h"], sd.cStrings["c"]}
+		sd.outputNames = []*C.char{sd.cStrings["output"], sd.cStrings["hn"], sd.cStrings["cn"]}
+	} else {
+		sd.inputNames = []*C.char{sd.cStrings["input"], sd.cStrings["state"], sd.cStrings["sr"]}
+		sd.outputNames = []*C.char{sd.cStrings["output"], sd.cStrings["stateN"]}
+	}
+	sd.inputValues = make([]*C.OrtValue, 0, len(sd.inputNames))
+	sd.outputValues = make([]*C.OrtValue, len(sd.outputNames))
+	sd.inputBuf = make([]float32, 0, sd.contextLen()+sd.windowSize())
+	sd.rate[0] = C.int64_t(sd.cfg.SampleRate)
+	sd.rateFloat[0] = float32(sd.cfg.SampleRate)
+
+	if sd.cfg.HighPassHz > 0 {
+		sd.highPass = newHighPass(float64(sd.cfg.HighPassHz), float64(sd.cfg.SampleRate))
+	}
+
+	return nil
 }
 
-func (sd *Detector) Reset() error {
-	if sd == nil {
-		return fmt.Errorf("invalid nil detector")
+// sessionInputNames returns the names of the inputs of the model.
+func sessionInputNames(api *C.OrtApi, session *C.OrtSession) ([]string, error) {
+	return sessionNames(api, session, "input",
+		func(count *C.size_t) *C.OrtStatus {
+			return C.OrtApiSessionGetInputCount(api, session, count)
+		},
+		func(i C.size_t, allocator *C.OrtAllocator, name **C.char) *C.OrtStatus {
+			return C.OrtApiSessionGetInputName(api, session, i, allocator, name)
+		})
+}
+
+// sessionInputElementType returns the element type of the tensor input of the model
+// at index.
+func sessionInputElementType(api *C.OrtApi, session *C.OrtSession, index int) (C.ONNXTensorElementDataType, error) {
+	var elementType C.ONNXTensorElementDataType
+	err := sessionInputTensorInfo(api, session, index, func(tensorInfo *C.OrtTensorTypeAndShapeInfo) error {
+		status := C.OrtApiGetTensorElementType(api, tensorInfo, &elementType)
+		defer C.OrtApiReleaseStatus(api, status)
+		if status != nil {
+			return fmt.Errorf("failed to get input element type: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+		}
+		return nil
+	})
+	return elementType, err
+}
+
+// sessionInputShape returns the shape of the tensor input of the model at index, in
+// which dynamic dimensions are negative.
+func sessionInputShape(api *C.OrtApi, session *C.OrtSession, index int) ([]int64, error) {
+	var shape []int64
+	err := sessionInputTensorInfo(api, session, index, func(tensorInfo *C.OrtTensorTypeAndShapeInfo) error {
+		var count C.size_t
+		status := C.OrtApiGetDimensionsCount(api, tensorInfo, &count)
+		defer C.OrtApiReleaseStatus(api, status)
+		if status != nil {
+			return fmt.Errorf("failed to get input dimensions count: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+		}
+		if count == 0 {
+			return nil
+		}
+
+		dims := make([]C.int64_t, count)
+		status = C.OrtApiGetDimensions(api, tensorInfo, &dims[0], count)
+		defer C.OrtApiReleaseStatus(api, status)
+		if status != nil {
+			return fmt.Errorf("failed to get input dimensions: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+		}
+
+		shape = make([]int64, count)
+		for i, dim := range dims {
+			shape[i] = int64(dim)
+		}
+		return nil
+	})
+	return shape, err
+}
+
+// sessionInputTensorInfo calls fn with the tensor type and shape info of the input of
+// the model at index, which is only valid during the call.
+func sessionInputTensorInfo(api *C.OrtApi, session *C.OrtSession, index int, fn func(tensorInfo *C.OrtTensorTypeAndShapeInfo) error) error {
+	var typeInfo *C.OrtTypeInfo
+	status := C.OrtApiSessionGetInputTypeInfo(api, session, C.size_t(index), &typeInfo)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return fmt.Errorf("failed to get input type info: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
 	}
+	defer C.OrtApiReleaseTypeInfo(api, typeInfo)
 
-	sd.currSample = 0
-	sd.triggered = false
-	sd.tempEnd = 0
-	for i := 0; i < stateLen; i++ {
-		sd.state[i] = 0
+	var tensorInfo *C.OrtTensorTypeAndShapeInfo
+	status = C.OrtApiCastTypeInfoToTensorInfo(api, typeInfo, &tensorInfo)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return fmt.Errorf("failed to get input tensor info: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
 	}
-	for i := 0; i < contextLen; i++ {
-		sd.ctx[i] = 0
+
+	return fn(tensorInfo)
+}
+
+// checkBatchDim verifies the batch dimension, at the given axis of the shape declared
+// by the model for an input, is compatible with the single element batches inference
+// is run with: either fixed to 1 or dynamic, e.g. for models exported with a symbolic
+// batch dimension, in which case it's set to 1 by the tensors passed to the model.
+// Shapes not declaring the axis are left for ONNX Runtime to check.
+func checkBatchDim(name string, shape []int64, axis int) error {
+	if axis >= len(shape) {
+		return nil
+	}
+
+	if dim := shape[axis]; dim > 1 {
+		return fmt.Errorf("unsupported model input %q: batch dimension is %d, should be 1 or dynamic", name, dim)
+	} else if dim <= 0 {
+		slog.Debug("model input has a dynamic batch dimension, using 1", slog.String("name", name))
 	}
 
 	return nil
 }
 
-func (sd *Detector) SetThreshold(value float32) {
-	sd.cfg.Threshold = value
+// sessionOutputNames returns the names of the outputs of the model.
+func sessionOutputNames(api *C.OrtApi, session *C.OrtSession) ([]string, error) {
+	return sessionNames(api, session, "output",
+		func(count *C.size_t) *C.OrtStatus {
+			return C.OrtApiSessionGetOutputCount(api, session, count)
+		},
+		func(i C.size_t, allocator *C.OrtAllocator, name **C.char) *C.OrtStatus {
+			return C.OrtApiSessionGetOutputName(api, session, i, allocator, name)
+		})
 }
 
-func (sd *Detector) SetNegativeThreshold(value float32) {
-	sd.cfg.NegativeThreshold = value
+// sessionNames returns the names of the inputs or outputs of the model (as told by
+// kind) through the given count and name getters.
+func sessionNames(api *C.OrtApi, session *C.OrtSession, kind string,
+	getCount func(count *C.size_t) *C.OrtStatus,
+	getName func(i C.size_t, allocator *C.OrtAllocator, name **C.char) *C.OrtStatus,
+) ([]string, error) {
+	var allocator *C.OrtAllocator
+	status := C.OrtApiGetAllocatorWithDefaultOptions(api, &allocator)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get allocator: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+	}
+
+	var count C.size_t
+	status = getCount(&count)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get %s count: %s", kind, C.GoString(C.OrtApiGetErrorMessage(api, status)))
+	}
+
+	names := make([]string, 0, int(count))
+	for i := 0; i < int(count); i++ {
+		var name *C.char
+		status := getName(C.size_t(i), allocator, &name)
+		defer C.OrtApiReleaseStatus(api, status)
+		if status != nil {
+			return nil, fmt.Errorf("failed to get %s name: %s", kind, C.GoString(C.OrtApiGetErrorMessage(api, status)))
+		}
+		names = append(names, C.GoString(name))
+		C.OrtApiReleaseStatus(api, C.OrtApiAllocatorFree(api, allocator, unsafe.Pointer(name)))
+	}
+
+	return names, nil
 }
 
-func (sd *Detector) SetMinSpeechDurationMs(value int) {
-	sd.cfg.MinSpeechDurationMs = value
+// hasSplitState reports whether a model with the given input names takes its state
+// as separate h and c tensors of shape [2, 1, 64] instead of a single state tensor of
+// shape [2, 1, 128].
+func hasSplitState(inputNames []string) bool {
+	var h, c bool
+	for _, name := range inputNames {
+		h = h || name == "h"
+		c = c || name == "c"
+	}
+	return h && c
 }
 
-func (sd *Detector) Destroy() error {
+// Session returns the underlying ONNX Runtime session (an OrtSession pointer) so that
+// it can be shared with other detectors through NewDetectorFromSession. The session
+// remains owned by sd and is released by its Destroy.
+func (sd *Detector) Session() unsafe.Pointer {
+	return unsafe.Pointer(sd.session)
+}
+
+// Segment contains timing information of a speech segment.
+type Segment struct {
+	// The relative timestamp in seconds of when a speech segment begins.
+	SpeechStartAt float64
+	// The relative timestamp in seconds of when a speech segment ends.
+	SpeechEndAt float64
+	// The number of windows within the segment with a speech probability above the threshold.
+	VoicedWindows int
+	// The number of windows processed while the segment was in progress, starting with
+	// those that led to the trigger and up to the one ending it, excluded.
+	Windows int
+	// The mean and peak speech probabilities of these windows.
+	MeanProbability float32
+	PeakProbability float32
+	// The grade of the segment, derived from MeanProbability and the ratio of voiced
+	// windows according to QualityThresholds.
+	Quality Quality
+	// Whether the segment was still in progress at the end of the input, in which
+	// case SpeechEndAt is zero, except for the partial segments passed to
+	// StreamCallbacks.OnSpeechPartial.
+	Unfinished bool
+	// The estimated signal-to-noise ratio of the segment in dB, set when EstimateSNR is
+	// enabled. See estimateSNR for how it's computed.
+	SNR float32
+	// The RMS envelope of the segment audio, with one value per EnvelopeResolutionMs,
+	// set when EnvelopeResolutionMs is enabled. Useful to draw the waveform of segments.
+	Envelope []float32
+}
+
+// Detect runs speech detection over pcm, processed in fixed size windows. Trailing
+// samples not filling a whole window are not processed. To detect speech over audio
+// received in chunks use a StreamDetector, whose results don't depend on how the audio
+// is split.
+func (sd *Detector) Detect(pcm []float32) ([]Segment, error) {
 	if sd == nil {
-		return fmt.Errorf("invalid nil detector")
+		return nil, fmt.Errorf("invalid nil detector")
 	}
 
-	C.OrtApiReleaseMemoryInfo(sd.api, sd.memoryInfo)
-	C.OrtApiReleaseSession(sd.api, sd.session)
-	C.OrtApiReleaseSessionOptions(sd.api, sd.sessionOpts)
-	C.OrtApiReleaseEnv(sd.api, sd.env)
-	for _, ptr := range sd.cStrings {
-		C.free(unsafe.Pointer(ptr))
+	return sd.detect(pcm, nil)
+}
+
+// DetectRange runs speech detection over the part of pcm between startSec and endSec,
+// in seconds, which must lie within pcm. The rest of the input isn't processed, while
+// the timestamps of the segments are on the timeline of the whole pcm, starting at
+// zero. Unlike Detect, the detection state is reset first since the range is
+// unrelated to the audio previously processed.
+func (sd *Detector) DetectRange(pcm []float32, startSec, endSec float64) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
 	}
 
-	return nil
+	if startSec < 0 {
+		return nil, fmt.Errorf("invalid startSec: should be a positive number")
+	}
+	if endSec <= startSec {
+		return nil, fmt.Errorf("invalid endSec: should be greater than startSec")
+	}
+	if duration := float64(len(pcm)) / float64(sd.cfg.SampleRate); endSec > duration {
+		return nil, fmt.Errorf("invalid endSec: should not exceed the input duration (%gs)", duration)
+	}
+
+	start := int(math.Round(startSec * float64(sd.cfg.SampleRate)))
+	end := min(int(math.Round(endSec*float64(sd.cfg.SampleRate))), len(pcm))
+
+	if err := sd.Reset(); err != nil {
+		return nil, err
+	}
+	// Start counting from the range start so that timestamps are on the pcm timeline.
+	sd.currSample = start
+
+	return sd.detect(pcm[start:end], nil)
+}
+
+// DetectIter works like Detect but returns an iterator yielding the segments as they
+// are finalized while processing pcm, so that they can be consumed before the whole
+// input is processed. A segment still in progress at the end of the input is yielded
+// last, as unfinished. On failure the error is yielded along with a zero Segment,
+// ending the iteration, while stopping the iteration early stops detection.
+//
+// The iterator is an iter.Seq2[Segment, error], so that on Go 1.23 and later it can
+// be ranged over:
+//
+//	for segment, err := range sd.DetectIter(pcm) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Detection is run on top of the streaming core, so as with StreamDetector,
+// PadShortInput, EstimateSNR, EnvelopeResolutionMs, TightBoundaries, IgnoreFirstMs,
+// IgnoreLastMs, SingleUtterance and KeepShortLastSegment don't apply, and when the
+// length of pcm is a multiple of the window size, the final window, which Detect
+// skips, is processed.
+func (sd *Detector) DetectIter(pcm []float32) func(yield func(Segment, error) bool) {
+	return func(yield func(Segment, error) bool) {
+		if sd == nil {
+			yield(Segment{}, fmt.Errorf("invalid nil detector"))
+			return
+		}
+
+		if err := sd.checkInputLen(len(pcm)); err != nil {
+			yield(Segment{}, err)
+			return
+		}
+
+		var stopped bool
+		s := &StreamDetector{
+			sd:     sd,
+			window: make([]float32, 0, sd.windowSize()),
+			callbacks: StreamCallbacks{
+				OnSpeechEnd: func(segment Segment) bool {
+					stopped = !yield(segment, nil)
+					return !stopped
+				},
+			},
+		}
+
+		if _, err := s.Process(pcm); err != nil {
+			yield(Segment{}, err)
+			return
+		}
+
+		if !stopped && s.open {
+			yield(sd.outputSegment(s.current), nil)
+		}
+	}
+}
+
+// detect runs speech detection over pcm, calling onWindow, if set, with the
+// decision taken for each window.
+func (sd *Detector) detect(pcm []float32, onWindow func(WindowDecision)) ([]Segment, error) {
+	if err := sd.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	pcm = sd.prepareInput(pcm)
+	input := pcm
+
+	callStart := sd.currSample
+	callEnd := sd.currSample + len(pcm)
+
+	padSamples := sd.shortInputPad(len(pcm))
+	if padSamples > 0 {
+		padded := make([]float32, padSamples+len(pcm)+padSamples)
+		copy(padded[padSamples:], pcm)
+		pcm = padded
+	}
+
+	if err := sd.checkInputLen(len(pcm)); err != nil {
+		return nil, err
+	}
+
+	slog.Debug("starting speech detection", slog.Int("samplesLen", len(pcm)))
+
+	return sd.segment(func(fn func(speechProb float32) error) error {
+		return sd.inferWindows(pcm, fn)
+	}, input, callStart, callEnd, padSamples, onWindow)
+}
+
+// segment runs the segmentation over the speech probabilities of the windows of an
+// input spanning the [callStart, callEnd) samples, as passed to fn by windows, and
+// post-processes the resulting segments. The input samples are used by the estimation
+// of SNR and envelopes, which are skipped when nil. padSamples is the length of the
+// silence the input was padded with on each side, see PadShortInput.
+func (sd *Detector) segment(windows func(fn func(speechProb float32) error) error, input []float32,
+	callStart, callEnd, padSamples int, onWindow func(WindowDecision)) ([]Segment, error) {
+	windowSize := sd.windowSize()
+	totalSamples := callEnd - callStart
+	var processed int
+
+	var segments []Segment
+	// The onset of each segment, see onsetSample.
+	var onsets []int
+	// The bounds in samples of the voiced windows of each segment, when trimming them.
+	var voiced [][2]int
+	err := windows(func(speechProb float32) error {
+		processed++
+		if sd.cfg.OnProgress != nil && processed%progressWindows == 0 {
+			sd.cfg.OnProgress(min(max(sd.currSample-callStart-padSamples, 0), totalSamples), totalSamples)
+		}
+
+		wasTriggered := sd.triggered
+		event, at := sd.step(speechProb)
+		if onWindow != nil {
+			onWindow(WindowDecision{
+				Sample:         sd.currSample,
+				Probability:    speechProb,
+				Triggered:      sd.triggered,
+				TriggerChanged: sd.triggered != wasTriggered,
+				TempEnd:        sd.tempEnd,
+			})
+		}
+
+		switch event {
+		case speechEventStart:
+			segments = append(segments, sd.startSegment(at, speechProb))
+			onsets = append(onsets, sd.onsetSample)
+			if sd.cfg.TightBoundaries {
+				// The windows that led to the trigger are all voiced.
+				voiced = append(voiced, [2]int{sd.currSample - sd.cfg.TriggerWindows*windowSize, sd.currSample})
+			}
+		case speechEventEnd:
+			if len(segments) < 1 {
+				return fmt.Errorf("unexpected speech end: %d segments collected, at sample %d (%.3fs)",
+					len(segments), sd.currSample, float64(sd.currSample)/float64(sd.cfg.SampleRate))
+			}
+
+			// The end can't precede the start, even with the padding math of an early trigger.
+			segments[len(segments)-1].SpeechEndAt = max(at, segments[len(segments)-1].SpeechStartAt)
+			segments[len(segments)-1].Unfinished = false
+		}
+
+		if sd.triggered && len(segments) > 0 {
+			sd.addWindow(&segments[len(segments)-1], speechProb)
+			// Checked by length rather than through the config, which Reconfigure may
+			// change during detection.
+			if speechProb >= sd.cfg.Threshold && len(voiced) == len(segments) {
+				voiced[len(voiced)-1][1] = sd.currSample
+			}
+		}
+
+		return nil
+	})
+	// Return the segments detected so far when closed midway.
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, err
+	}
+
+	if sd.cfg.CloseOpenSegments && err == nil && sd.triggered && sd.tempEnd != 0 && len(segments) > 0 {
+		segments[len(segments)-1].SpeechEndAt = sd.tempEndAt()
+		segments[len(segments)-1].Unfinished = false
+		sd.tempEnd = 0
+		sd.triggered = false
+		slog.Debug("closed open speech segment", slog.Float64("endAt", segments[len(segments)-1].SpeechEndAt))
+	}
+
+	if len(voiced) == len(segments) {
+		for i, bounds := range voiced {
+			segments[i].SpeechStartAt = float64(bounds[0]) / float64(sd.cfg.SampleRate)
+			if !segments[i].Unfinished {
+				segments[i].SpeechEndAt = float64(bounds[1]) / float64(sd.cfg.SampleRate)
+			}
+		}
+	}
+
+	if padSamples > 0 {
+		// Shift timestamps back onto the timeline of the unpadded input.
+		padSec := float64(padSamples) / float64(sd.cfg.SampleRate)
+		startSec := float64(callStart) / float64(sd.cfg.SampleRate)
+		endSec := float64(callEnd) / float64(sd.cfg.SampleRate)
+		for i := range segments {
+			segments[i].SpeechStartAt = min(max(segments[i].SpeechStartAt-padSec, startSec), endSec)
+			if !segments[i].Unfinished {
+				segments[i].SpeechEndAt = min(max(segments[i].SpeechEndAt-padSec, segments[i].SpeechStartAt), endSec)
+			}
+			onsets[i] = min(max(onsets[i]-padSamples, callStart), callEnd)
+		}
+
+		sd.currSample = min(sd.currSample-padSamples, callEnd)
+		if sd.tempEnd != 0 {
+			sd.tempEnd = min(sd.tempEnd-padSamples, callEnd)
+		}
+	}
+
+	slog.Debug("speech detection done", slog.Int("segmentsLen", len(segments)))
+
+	// The last finished segment, kept even if too short when KeepShortLastSegment is set.
+	lastFinished := -1
+	if sd.cfg.KeepShortLastSegment {
+		for i := len(segments) - 1; i >= 0; i-- {
+			if !segments[i].Unfinished {
+				lastFinished = i
+				break
+			}
+		}
+	}
+
+	// Filter out segments that are too short, as well as empty ones
+	var filteredSegments []Segment
+	var filteredOnsets []int
+	for i, segment := range segments {
+		// Skip segments that don't have an end time yet
+		if segment.Unfinished {
+			filteredSegments = append(filteredSegments, segment)
+			filteredOnsets = append(filteredOnsets, onsets[i])
+			continue
+		}
+
+		if !sd.tooShort(segment) || (i == lastFinished && segment.SpeechEndAt > segment.SpeechStartAt) {
+			filteredSegments = append(filteredSegments, segment)
+			filteredOnsets = append(filteredOnsets, onsets[i])
+		} else {
+			slog.Debug("filtered out short speech segment",
+				slog.Float64("startAt", segment.SpeechStartAt),
+				slog.Float64("endAt", segment.SpeechEndAt),
+				slog.Float64("duration", segment.SpeechEndAt-segment.SpeechStartAt),
+				slog.Int("minDuration", sd.cfg.MinSpeechDurationMs))
+		}
+	}
+	segments, onsets = filteredSegments, filteredOnsets
+
+	if sd.cfg.SingleUtterance && len(segments) > 1 {
+		segments, onsets = []Segment{mergeSegments(segments)}, onsets[:1]
+	}
+
+	for i := range segments {
+		segments[i].Quality = sd.cfg.QualityThresholds.grade(segments[i])
+	}
+
+	if sd.cfg.FrameAlignMs > 0 {
+		for i := range segments {
+			segments[i] = sd.alignToFrames(segments[i])
+		}
+	}
+
+	if sd.cfg.IgnoreFirstMs > 0 || sd.cfg.IgnoreLastMs > 0 {
+		segments, onsets = sd.ignoreMargins(segments, onsets, callEnd)
+	}
+
+	if sd.cfg.EstimateSNR && input != nil {
+		sd.estimateSNR(segments, input, callStart)
+	}
+
+	if sd.cfg.EnvelopeResolutionMs > 0 && input != nil {
+		sd.computeEnvelopes(segments, input, callStart)
+	}
+
+	if sd.cfg.StartOffsetSec > 0 {
+		for i := range segments {
+			segments[i] = sd.withOffset(segments[i])
+		}
+	}
+
+	if sd.cfg.SegmentFilter != nil {
+		kept := segments[:0]
+		keptOnsets := onsets[:0]
+		for i, segment := range segments {
+			if !segment.Unfinished {
+				var keep bool
+				if segment, keep = sd.cfg.SegmentFilter(segment); !keep {
+					continue
+				}
+			}
+			kept = append(kept, segment)
+			keptOnsets = append(keptOnsets, onsets[i])
+		}
+		segments, onsets = kept, keptOnsets
+	}
+	sd.onsets = onsets
+
+	if sd.cfg.OnProgress != nil && err == nil {
+		sd.cfg.OnProgress(totalSamples, totalSamples)
+	}
+
+	return segments, err
+}
+
+// SpeechRatio runs inference over pcm and returns the fraction of windows whose speech
+// probability is at or above the configured threshold. It's a cheaper alternative to Detect
+// when only a coarse measure of how much speech the audio contains is needed.
+// As with Detect, the model state carries over between calls so Reset should be called
+// before processing unrelated audio.
+func (sd *Detector) SpeechRatio(pcm []float32) (float64, error) {
+	if sd == nil {
+		return 0, fmt.Errorf("invalid nil detector")
+	}
+
+	pcm = sd.prepareInput(pcm)
+	if err := sd.checkInputLen(len(pcm)); err != nil {
+		return 0, err
+	}
+
+	var windows, speechWindows int
+	err := sd.inferWindows(pcm, func(speechProb float32) error {
+		windows++
+		if speechProb >= sd.cfg.Threshold {
+			speechWindows++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if windows == 0 {
+		return 0, nil
+	}
+
+	return float64(speechWindows) / float64(windows), nil
+}
+
+// DetectMask runs inference over pcm and returns whether each window holds speech, as
+// decided by the thresholds alone: a window holds speech if its speech probability is
+// at or above Threshold, not if it's below NegativeThreshold, and otherwise like the
+// previous window (or not, with MidRangePolicyTreatAsSilence). Probabilities are
+// smoothed as configured, but segment level settings such as TriggerWindows,
+// MinSilenceDurationMs, MinSpeechDurationMs and SpeechPadMs don't apply.
+//
+// The i-th value covers the samples of pcm in [i*w, (i+1)*w), w being the window size:
+// 512 samples at 16000 Hz and 256 at 8000 Hz. Trailing samples past the last window
+// processed are not covered. As with SpeechRatio, PadShortInput doesn't apply and the
+// model state carries over between calls.
+func (sd *Detector) DetectMask(pcm []float32) ([]bool, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	pcm = sd.prepareInput(pcm)
+	if err := sd.checkInputLen(len(pcm)); err != nil {
+		return nil, err
+	}
+
+	mask := make([]bool, 0, len(pcm)/sd.windowSize())
+	var speech bool
+	err := sd.inferWindows(pcm, func(speechProb float32) error {
+		switch {
+		case speechProb >= sd.cfg.Threshold:
+			speech = true
+		case speechProb < sd.cfg.NegativeThreshold:
+			speech = false
+		case sd.cfg.MidRangePolicy == MidRangePolicyTreatAsSilence:
+			speech = false
+		}
+		mask = append(mask, speech)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mask, nil
+}
+
+// errSpeechFound stops inference early once HasSpeech found speech.
+var errSpeechFound = errors.New("speech found")
+
+// HasSpeech reports whether pcm contains any speech, returning as soon as a window with
+// a speech probability at or above the threshold is found, without processing the rest
+// of the input. If HasSpeechMinDuration is set, speech has to last at least
+// MinSpeechDurationMs over consecutive windows instead.
+// As with Detect, the model state carries over between calls so Reset should be called
+// before processing unrelated audio.
+func (sd *Detector) HasSpeech(pcm []float32) (bool, error) {
+	if sd == nil {
+		return false, fmt.Errorf("invalid nil detector")
+	}
+
+	pcm = sd.prepareInput(pcm)
+	if err := sd.checkInputLen(len(pcm)); err != nil {
+		return false, err
+	}
+
+	minSpeechSamples := 1
+	if sd.cfg.HasSpeechMinDuration {
+		minSpeechSamples = max(sd.cfg.MinSpeechDurationMs*sd.cfg.SampleRate/1000, 1)
+	}
+
+	var speechSamples int
+	err := sd.inferWindows(pcm, func(speechProb float32) error {
+		if speechProb < sd.cfg.Threshold {
+			speechSamples = 0
+			return nil
+		}
+
+		speechSamples += sd.windowSize()
+		if speechSamples >= minSpeechSamples {
+			return errSpeechFound
+		}
+		return nil
+	})
+	if errors.Is(err, errSpeechFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// windowSize returns the number of samples processed by each inference call.
+func (sd *Detector) windowSize() int {
+	return windowSizeFor(sd.cfg.SampleRate)
+}
+
+// windowSizeFor returns the number of samples of the windows the model processes at
+// sampleRate.
+func windowSizeFor(sampleRate int) int {
+	if sampleRate == 8000 {
+		return 256
+	}
+	return 512
+}
+
+// contextLen returns the number of samples of the previous window preceding each
+// window in the input of single state models, which depends on the sample rate as with
+// the reference implementation.
+func (sd *Detector) contextLen() int {
+	if sd.cfg.SampleRate == 8000 {
+		return 32
+	}
+	return maxContextLen
+}
+
+// WindowCount returns the number of windows Detect processes for an input of
+// numSamples samples with the current config, including the windows of silence added by
+// PadShortInput, or zero if the input is too short to run detection on. Each window
+// requires an inference call, so it can be used to estimate processing time beforehand.
+func (sd *Detector) WindowCount(numSamples int) int {
+	windowSize := sd.windowSize()
+	numSamples += 2 * sd.shortInputPad(numSamples)
+
+	if numSamples <= 0 || sd.checkInputLen(numSamples) != nil {
+		return 0
+	}
+
+	// Matches inferWindows, which stops short of a window ending exactly at the end of
+	// the input.
+	return (numSamples - 1) / windowSize
+}
+
+// shortInputPad returns the number of samples of silence added on each side of an input
+// of numSamples samples, see PadShortInput.
+func (sd *Detector) shortInputPad(numSamples int) int {
+	if sd.cfg.PadShortInput && numSamples*1000 < shortInputMaxMs*sd.cfg.SampleRate {
+		return shortInputPadWindows * sd.windowSize()
+	}
+	return 0
+}
+
+// WindowSize returns the number of samples of the windows the model processes at the
+// configured sample rate: 512 at 16000 Hz and 256 at 8000 Hz. Segment boundaries fall
+// on window boundaries unless refined, so it's the granularity of the timestamps, and
+// feeding a StreamDetector chunks of this size processes a window per chunk.
+func (sd *Detector) WindowSize() int {
+	return sd.windowSize()
+}
+
+// HopSize returns the number of samples between the starts of consecutive windows.
+// Windows don't overlap, so it equals WindowSize: the context the model takes along
+// with each window is carried over from the previous one.
+func (sd *Detector) HopSize() int {
+	return sd.windowSize()
+}
+
+// checkInputLen verifies an input of n samples is long enough to run detection on.
+func (sd *Detector) checkInputLen(n int) error {
+	if n >= sd.cfg.MinWindowsForContext*sd.windowSize() {
+		return nil
+	}
+
+	if sd.cfg.MinWindowsForContext > 1 {
+		return fmt.Errorf("not enough samples: at least %d windows are needed for context", sd.cfg.MinWindowsForContext)
+	}
+
+	return fmt.Errorf("not enough samples")
+}
+
+// prepareInput applies the configured InputScale and high-pass filter to pcm,
+// returning a processed copy when needed, and warns (once) if the samples don't look
+// normalized.
+func (sd *Detector) prepareInput(pcm []float32) []float32 {
+	if sd.cfg.InputScale != 1 || sd.highPass != nil {
+		scaled := make([]float32, len(pcm))
+		for i, sample := range pcm {
+			scaled[i] = sample * sd.cfg.InputScale
+		}
+		pcm = scaled
+	}
+
+	if !sd.inputScaleWarned {
+		for _, sample := range pcm {
+			if sample > maxExpectedMagnitude || sample < -maxExpectedMagnitude {
+				slog.Warn("input samples exceed the expected [-1, 1] range, InputScale may need to be set",
+					slog.Float64("sample", float64(sample)))
+				sd.inputScaleWarned = true
+				break
+			}
+		}
+	}
+
+	if sd.highPass != nil {
+		sd.highPass.process(pcm)
+	}
+
+	return pcm
+}
+
+// inferWindows runs inference over consecutive windows of pcm, advancing the detector
+// position and calling fn with the speech probability of each window.
+func (sd *Detector) inferWindows(pcm []float32, fn func(speechProb float32) error) error {
+	windowSize := sd.windowSize()
+	for i := 0; i < len(pcm)-windowSize; i += windowSize {
+		speechProb, err := sd.inferWindow(pcm[i : i+windowSize])
+		if err != nil {
+			return err
+		}
+
+		if err := fn(speechProb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inferWindow runs inference over a single window, advancing the detector position.
+func (sd *Detector) inferWindow(window []float32) (float32, error) {
+	sd.closeMu.Lock()
+	if sd.closed {
+		sd.closeMu.Unlock()
+		return 0, ErrDetectorClosed
+	}
+	sd.inferring.Add(1)
+	sd.closeMu.Unlock()
+	defer sd.inferring.Done()
+
+	sd.applyPendingConfig()
+
+	sem := acquireInference()
+
+	var start time.Time
+	if sd.cfg.CollectLatency {
+		start = time.Now()
+	}
+
+	speechProb, err := sd.infer(sd.modelInput(window))
+	releaseInference(sem)
+
+	if sd.cfg.CollectLatency {
+		sd.latencies = append(sd.latencies, time.Since(start))
+	}
+
+	if err != nil {
+		if !sd.cfg.SkipErrorWindows {
+			return 0, fmt.Errorf("infer failed: %w", err)
+		}
+		slog.Warn("infer failed, treating window as silence", slog.String("err", err.Error()))
+		sd.stats.ErrorWindows++
+		speechProb = 0
+	}
+	sd.stats.Windows++
+
+	sd.currSample += len(window)
+
+	return sd.smoothProb(speechProb), nil
+}
+
+// modelInput returns the input of the model for window. Models with a single state
+// tensor take the window preceded by the last contextLen samples of the previous one,
+// zeros at the start of the stream as with the reference implementation, which is
+// saved for the next window. Split state models predate the use of context and only
+// take the window.
+func (sd *Detector) modelInput(window []float32) []float32 {
+	if sd.splitState {
+		return window
+	}
+
+	ctx := sd.ctx[:sd.contextLen()]
+	sd.inputBuf = append(append(sd.inputBuf[:0], ctx...), window...)
+	copy(ctx, window[len(window)-len(ctx):])
+
+	return sd.inputBuf
+}
+
+// smoothProb returns the average of the speech probabilities of the last
+// ProbSmoothingWindows windows, including speechProb.
+func (sd *Detector) smoothProb(speechProb float32) float32 {
+	if sd.cfg.ProbSmoothingWindows <= 1 {
+		return speechProb
+	}
+
+	if len(sd.recentProbs) >= sd.cfg.ProbSmoothingWindows {
+		n := copy(sd.recentProbs, sd.recentProbs[len(sd.recentProbs)-sd.cfg.ProbSmoothingWindows+1:])
+		sd.recentProbs = sd.recentProbs[:n]
+	}
+	sd.recentProbs = append(sd.recentProbs, speechProb)
+
+	var sum float32
+	for _, p := range sd.recentProbs {
+		sum += p
+	}
+	return sum / float32(len(sd.recentProbs))
+}
+
+// speechEvent is a change of speech state caused by processing a window.
+type speechEvent int
+
+const (
+	speechEventNone speechEvent = iota
+	speechEventStart
+	speechEventEnd
+)
+
+// step advances the segmentation state machine with the speech probability of the
+// last processed window. It returns the change of speech state the window caused, if
+// any, along with the timestamp in seconds at which it happened.
+func (sd *Detector) step(speechProb float32) (speechEvent, float64) {
+	windowSize := sd.windowSize()
+	minSilenceSamples := sd.cfg.MinSilenceDurationMs * sd.cfg.SampleRate / 1000
+	speechPadSamples := sd.cfg.SpeechPadMs * sd.cfg.SampleRate / 1000
+
+	prevProb := sd.prevProb
+	sd.prevProb = speechProb
+
+	if sd.cfg.OnsetBackoffWindows > 0 {
+		if limit := sd.cfg.OnsetBackoffWindows + sd.cfg.TriggerWindows; len(sd.onsetProbs) >= limit {
+			n := copy(sd.onsetProbs, sd.onsetProbs[len(sd.onsetProbs)-limit+1:])
+			sd.onsetProbs = sd.onsetProbs[:n]
+		}
+		sd.onsetProbs = append(sd.onsetProbs, speechProb)
+	}
+
+	speech := speechProb >= sd.cfg.Threshold
+	silence := speechProb < sd.cfg.NegativeThreshold
+	if sd.triggered && !speech && !silence {
+		speech = sd.cfg.MidRangePolicy == MidRangePolicyTreatAsSpeech
+		silence = sd.cfg.MidRangePolicy == MidRangePolicyTreatAsSilence
+	}
+
+	if speech && sd.tempEnd != 0 {
+		sd.tempEnd = 0
+	}
+
+	if !speech {
+		sd.speechRun = 0
+	}
+
+	if speech && !sd.triggered {
+		sd.speechRun++
+		if sd.speechRun == 1 {
+			sd.speechRunFirstProb = speechProb
+			sd.speechRunPrevProb = prevProb
+			sd.speechRunProbSum = 0
+			sd.speechRunPeak = 0
+		}
+		sd.speechRunProbSum += float64(speechProb)
+		sd.speechRunPeak = max(sd.speechRunPeak, speechProb)
+		if sd.speechRun < sd.cfg.TriggerWindows {
+			return speechEventNone, 0
+		}
+
+		// The segment starts at the first of the consecutive speech windows, or at the
+		// first of the rising windows before them when backing off.
+		runStart := sd.currSample - sd.speechRun*windowSize
+		backoff := sd.onsetBackoff(sd.speechRun)
+		sd.onsetSample = runStart
+		sd.speechRun = 0
+		sd.triggered = true
+		speechStartAt := (float64(runStart-backoff*windowSize-speechPadSamples) / float64(sd.cfg.SampleRate))
+		if sd.cfg.RefineBoundaries && backoff == 0 {
+			crossingAt := float64(runStart) + crossingOffset(sd.speechRunPrevProb, sd.speechRunFirstProb, sd.cfg.Threshold, windowSize)
+			speechStartAt = (crossingAt - float64(speechPadSamples)) / float64(sd.cfg.SampleRate)
+		}
+
+		// We clamp at zero since due to padding the starting position could be negative,
+		// e.g. when triggering on the first windows. The end of the segment is at least
+		// one window past its unpadded start, so it still follows the clamped start.
+		if speechStartAt < 0 && !sd.cfg.AllowNegativeStart {
+			speechStartAt = 0
+		}
+
+		slog.Debug("speech start", slog.Float64("startAt", speechStartAt))
+		return speechEventStart, speechStartAt
+	}
+
+	if silence && sd.triggered {
+		if sd.tempEnd == 0 {
+			sd.tempEnd = sd.currSample
+			if sd.cfg.RefineBoundaries {
+				sd.tempEndRefined = float64(sd.currSample-windowSize) + crossingOffset(prevProb, speechProb, sd.cfg.NegativeThreshold, windowSize)
+			}
+		}
+
+		// Not enough silence yet to split, we continue.
+		if sd.currSample-sd.tempEnd < minSilenceSamples {
+			return speechEventNone, 0
+		}
+
+		speechEndAt := sd.tempEndAt()
+		sd.tempEnd = 0
+		sd.triggered = false
+		slog.Debug("speech end", slog.Float64("endAt", speechEndAt))
+
+		return speechEventEnd, speechEndAt
+	}
+
+	return speechEventNone, 0
+}
+
+// onsetBackoff returns the number of windows, up to OnsetBackoffWindows, directly
+// preceding the run of speech windows of the given length whose speech probability was
+// rising towards it.
+func (sd *Detector) onsetBackoff(run int) int {
+	if sd.cfg.OnsetBackoffWindows <= 0 {
+		return 0
+	}
+
+	before := sd.onsetProbs[:max(len(sd.onsetProbs)-run, 0)]
+	next := sd.speechRunFirstProb
+	var n int
+	for n < sd.cfg.OnsetBackoffWindows && n < len(before) {
+		prob := before[len(before)-1-n]
+		if prob >= next {
+			break
+		}
+		next = prob
+		n++
+	}
+
+	return n
+}
+
+// tempEndAt returns the timestamp in seconds at which the current segment ends when
+// ending at the silence that began at tempEnd.
+func (sd *Detector) tempEndAt() float64 {
+	speechPadSamples := sd.cfg.SpeechPadMs * sd.cfg.SampleRate / 1000
+	if sd.cfg.RefineBoundaries {
+		return (sd.tempEndRefined + float64(speechPadSamples)) / float64(sd.cfg.SampleRate)
+	}
+	return float64(sd.tempEnd+speechPadSamples) / float64(sd.cfg.SampleRate)
+}
+
+// crossingOffset estimates where the speech probability crosses threshold between two
+// consecutive windows with probabilities from and to, assuming it varies linearly between
+// the windows' centers. The result is the offset in samples from the boundary between the
+// windows, in the [-windowSize/2, windowSize/2] range.
+func crossingOffset(from, to, threshold float32, windowSize int) float64 {
+	if from == to {
+		return 0
+	}
+
+	frac := float64((threshold - from) / (to - from))
+	frac = min(max(frac, 0), 1)
+
+	return (frac - 0.5) * float64(windowSize)
+}
+
+// tooShort reports whether a finished segment is empty or lasts less than
+// MinSpeechDurationMs.
+func (sd *Detector) tooShort(segment Segment) bool {
+	minSpeechSamples := sd.cfg.MinSpeechDurationMs * sd.cfg.SampleRate / 1000
+	durationSamples := (segment.SpeechEndAt - segment.SpeechStartAt) * float64(sd.cfg.SampleRate)
+	// Empty segments are dropped even when MinSpeechDurationMs is zero.
+	return durationSamples <= 0 || durationSamples < float64(minSpeechSamples)
+}
+
+// mergeSegments returns a segment spanning from the start of the first of segments to
+// the end of the last one, unfinished if the last one is.
+func mergeSegments(segments []Segment) Segment {
+	merged := segments[0]
+	last := segments[len(segments)-1]
+	merged.SpeechEndAt = last.SpeechEndAt
+	merged.Unfinished = last.Unfinished
+	var probSum float64
+	for _, segment := range segments {
+		probSum += float64(segment.MeanProbability) * float64(segment.Windows)
+	}
+	for _, segment := range segments[1:] {
+		merged.VoicedWindows += segment.VoicedWindows
+		merged.Windows += segment.Windows
+		merged.PeakProbability = max(merged.PeakProbability, segment.PeakProbability)
+	}
+	if merged.Windows > 0 {
+		merged.MeanProbability = float32(probSum / float64(merged.Windows))
+	}
+	return merged
+}
+
+// ignoreMargins trims segments to exclude the IgnoreFirstMs at the start of the audio
+// and the IgnoreLastMs before end, in samples, dropping those left empty. The onsets
+// of the segments, see onsetSample, are trimmed and dropped along with them.
+func (sd *Detector) ignoreMargins(segments []Segment, onsets []int, end int) ([]Segment, []int) {
+	firstSec := float64(sd.cfg.IgnoreFirstMs) / 1000
+	lastSec := float64(end)/float64(sd.cfg.SampleRate) - float64(sd.cfg.IgnoreLastMs)/1000
+
+	kept := segments[:0]
+	keptOnsets := onsets[:0]
+	for i, segment := range segments {
+		segment.SpeechStartAt = max(segment.SpeechStartAt, firstSec)
+		if segment.SpeechStartAt >= lastSec {
+			continue
+		}
+		if !segment.Unfinished {
+			segment.SpeechEndAt = min(segment.SpeechEndAt, lastSec)
+			if segment.SpeechEndAt <= segment.SpeechStartAt {
+				continue
+			}
+		}
+		kept = append(kept, segment)
+		keptOnsets = append(keptOnsets, max(onsets[i], sd.cfg.IgnoreFirstMs*sd.cfg.SampleRate/1000))
+	}
+
+	return kept, keptOnsets
+}
+
+// outputSegment returns segment as emitted by the streaming paths, graded, aligned to
+// FrameAlignMs and with StartOffsetSec applied.
+func (sd *Detector) outputSegment(segment Segment) Segment {
+	segment.Quality = sd.cfg.QualityThresholds.grade(segment)
+	return sd.withOffset(sd.alignToFrames(segment))
+}
+
+// alignToFrames returns segment with its timestamps aligned to FrameAlignMs, if set.
+func (sd *Detector) alignToFrames(segment Segment) Segment {
+	if sd.cfg.FrameAlignMs <= 0 {
+		return segment
+	}
+
+	frameSec := float64(sd.cfg.FrameAlignMs) / 1000
+	// Tolerate the rounding errors of timestamps already on the frame grid.
+	const epsilon = 1e-6
+	roundDown, roundUp := math.Floor, math.Ceil
+	if sd.cfg.FrameAlignNearest {
+		roundDown, roundUp = math.Round, math.Round
+	}
+
+	segment.SpeechStartAt = roundDown(segment.SpeechStartAt/frameSec+epsilon) * frameSec
+	if !segment.Unfinished {
+		segment.SpeechEndAt = max(roundUp(segment.SpeechEndAt/frameSec-epsilon)*frameSec, segment.SpeechStartAt)
+	}
+	return segment
+}
+
+// withOffset returns segment with StartOffsetSec applied to its timestamps.
+func (sd *Detector) withOffset(segment Segment) Segment {
+	offset := sd.cfg.StartOffsetSec + sd.rateChangeOffsetSec
+	segment.SpeechStartAt += offset
+	if !segment.Unfinished {
+		segment.SpeechEndAt += offset
+	}
+	return segment
+}
+
+// DetectBytes decodes raw audio data encoded as format and runs speech detection on it.
+// Integer samples are normalized to the [-1, 1] range before detection.
+func (sd *Detector) DetectBytes(data []byte, format SampleFormat) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	pcm, err := DecodeSamples(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode samples: %w", err)
+	}
+
+	return sd.Detect(pcm)
+}
+
+// DetectInterleavedInt16 runs speech detection on interleaved int16 samples with the
+// given number of channels, as commonly produced by capture devices. The channels are
+// mixed down to mono by averaging them and normalized to the [-1, 1] range, into a
+// buffer kept by the detector and reused across calls.
+func (sd *Detector) DetectInterleavedInt16(data []int16, channels int) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if channels < 1 {
+		return nil, fmt.Errorf("invalid channels: should be a positive number")
+	}
+
+	if len(data)%channels != 0 {
+		return nil, fmt.Errorf("invalid data length: should be a multiple of %d", channels)
+	}
+
+	frames := len(data) / channels
+	if cap(sd.monoBuf) < frames {
+		sd.monoBuf = make([]float32, frames)
+	}
+	sd.monoBuf = sd.monoBuf[:frames]
+
+	scale := 1 / (32768 * float32(channels))
+	for i := range sd.monoBuf {
+		var sum int32
+		for _, sample := range data[i*channels : (i+1)*channels] {
+			sum += int32(sample)
+		}
+		sd.monoBuf[i] = float32(sum) * scale
+	}
+
+	return sd.Detect(sd.monoBuf)
+}
+
+// DetectMuLaw runs speech detection on G.711 µ-law audio, one byte per sample, as
+// commonly found in telephony. G.711 audio being sampled at 8 kHz, the detector must be
+// configured with a SampleRate of 8000.
+func (sd *Detector) DetectMuLaw(data []byte) ([]Segment, error) {
+	return sd.detectG711(data, audioutil.DecodeMuLaw)
+}
+
+// DetectALaw runs speech detection on G.711 A-law audio, as DetectMuLaw does on µ-law
+// audio.
+func (sd *Detector) DetectALaw(data []byte) ([]Segment, error) {
+	return sd.detectG711(data, audioutil.DecodeALaw)
+}
+
+func (sd *Detector) detectG711(data []byte, decode func([]byte) []float32) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if sd.cfg.SampleRate != 8000 {
+		return nil, fmt.Errorf("invalid SampleRate: should be 8000 for G.711 audio")
+	}
+
+	return sd.Detect(decode(data))
+}
+
+// DetectFile reads the audio file at path and runs speech detection on it. WAV and AIFF
+// files are detected from their header and must match the configured sample rate,
+// unless ResampleFiles is set; any other file is read as raw little-endian float32
+// samples, following HeaderBytes of header if set.
+func (sd *Detector) DetectFile(path string) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	samples, err := sd.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return sd.Detect(samples)
+}
+
+// readFile reads the samples of the audio file at path, as described by DetectFile.
+func (sd *Detector) readFile(path string) ([]float32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var (
+		samples    []float32
+		sampleRate int
+	)
+	switch {
+	case isWAV(data):
+		samples, sampleRate, err = DecodeWAV(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode WAV: %w", err)
+		}
+	case audioutil.IsAIFF(data):
+		samples, sampleRate, err = audioutil.DecodeAIFF(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode AIFF: %w", err)
+		}
+	default:
+		if len(data) < sd.cfg.HeaderBytes {
+			return nil, fmt.Errorf("invalid data length: should be at least HeaderBytes (%d)", sd.cfg.HeaderBytes)
+		}
+		samples, err := DecodeSamples(data[sd.cfg.HeaderBytes:], SampleFormatFloat32LE)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode samples: %w", err)
+		}
+		return samples, nil
+	}
+
+	if sampleRate != sd.cfg.SampleRate {
+		if !sd.cfg.ResampleFiles {
+			return nil, fmt.Errorf("invalid sample rate: file is %d Hz but the detector expects %d Hz", sampleRate, sd.cfg.SampleRate)
+		}
+		samples = audioutil.Resample(samples, sampleRate, sd.cfg.SampleRate)
+	}
+
+	return samples, nil
+}
+
+// DetectConcat runs speech detection over the audio files at paths, read as by
+// DetectFile, as if they were a single recording. Segments are timestamped on a global
+// timeline on which each file starts where the previous one ends. The detector is reset
+// beforehand, so that the timeline starts at zero, as well as afterwards.
+//
+// By default the detection state carries over between files, so that segments can span
+// over them. When ResetBetweenFiles is set, the state is reset at the start of each
+// file instead, a segment still in progress at the end of a file being returned as
+// unfinished. As with StreamDetector, PadShortInput, EstimateSNR, EnvelopeResolutionMs,
+// TightBoundaries, IgnoreFirstMs, IgnoreLastMs, SingleUtterance and KeepShortLastSegment
+// don't apply.
+func (sd *Detector) DetectConcat(paths []string) ([]Segment, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if err := sd.Reset(); err != nil {
+		return nil, err
+	}
+
+	s := &StreamDetector{
+		sd:     sd,
+		window: make([]float32, 0, sd.windowSize()),
+	}
+
+	var segments []Segment
+	// The start of the current file on the global timeline, in samples, when resetting
+	// between files.
+	var fileStart int
+	for i, path := range paths {
+		samples, err := sd.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		fileSegments, err := s.Process(samples)
+		if err != nil {
+			return nil, err
+		}
+
+		if sd.cfg.ResetBetweenFiles || i == len(paths)-1 {
+			flushed, err := s.Flush()
+			if err != nil {
+				return nil, err
+			}
+			fileSegments = append(fileSegments, flushed...)
+		}
+
+		if sd.cfg.ResetBetweenFiles {
+			offset := float64(fileStart) / float64(sd.cfg.SampleRate)
+			for j := range fileSegments {
+				fileSegments[j].SpeechStartAt += offset
+				if !fileSegments[j].Unfinished {
+					fileSegments[j].SpeechEndAt += offset
+				}
+			}
+			fileStart += len(samples)
+		}
+
+		segments = append(segments, fileSegments...)
+	}
+
+	return segments, nil
+}
+
+func (sd *Detector) Reset() error {
+	if sd == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	if err := sd.checkOpen(); err != nil {
+		return err
+	}
+
+	sd.currSample = 0
+	sd.triggered = false
+	sd.rateChangeOffsetSec = 0
+	sd.tempEnd = 0
+	sd.tempEndRefined = 0
+	sd.prevProb = 0
+	sd.speechRun = 0
+	sd.speechRunFirstProb = 0
+	sd.speechRunPrevProb = 0
+	sd.speechRunProbSum = 0
+	sd.speechRunPeak = 0
+	sd.recentProbs = sd.recentProbs[:0]
+	sd.onsetProbs = sd.onsetProbs[:0]
+	if sd.highPass != nil {
+		sd.highPass.reset()
+	}
+	sd.stats = DetectorStats{}
+	sd.latencies = sd.latencies[:0]
+	for i := 0; i < stateLen; i++ {
+		sd.state[i] = 0
+	}
+	for i := 0; i < maxContextLen; i++ {
+		sd.ctx[i] = 0
+	}
+
+	return nil
+}
+
+// setSampleRate switches the detector to sampleRate, which must be valid, updating the
+// buffers and filter depending on it. The detection state is left untouched.
+func (sd *Detector) setSampleRate(sampleRate int) {
+	sd.cfgMu.Lock()
+	sd.cfg.SampleRate = sampleRate
+	sd.initialCfg.SampleRate = sampleRate
+	if sd.pendingCfg != nil {
+		sd.pendingCfg.SampleRate = sampleRate
+	}
+	sd.cfgMu.Unlock()
+
+	sd.inputBuf = make([]float32, 0, sd.contextLen()+sd.windowSize())
+	sd.rate[0] = C.int64_t(sampleRate)
+	sd.rateFloat[0] = float32(sampleRate)
+	if sd.cfg.HighPassHz > 0 {
+		sd.highPass = newHighPass(float64(sd.cfg.HighPassHz), float64(sampleRate))
+	}
+}
+
+// LatencyStats summarizes the duration of the inference calls run since the detector
+// was created or last reset. It requires CollectLatency to be set, otherwise the
+// returned stats are empty.
+func (sd *Detector) LatencyStats() LatencyStats {
+	return newLatencyStats(sd.latencies)
+}
+
+// ResetConfig reverts any change applied through the setters (e.g. SetThreshold),
+// restoring the config the detector was created with. Unlike Reset, it doesn't
+// affect the detection state.
+func (sd *Detector) ResetConfig() error {
+	if sd == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	sd.updateConfig(func(cfg *DetectorConfig) {
+		*cfg = sd.initialCfg
+	})
+
+	return nil
+}
+
+// Config returns the configuration in use, with the defaults resolved (e.g. the derived
+// NegativeThreshold) and including the changes made through the setters. It's safe to
+// call while detection runs on another goroutine.
+func (sd *Detector) Config() DetectorConfig {
+	sd.cfgMu.Lock()
+	defer sd.cfgMu.Unlock()
+
+	cfg := sd.cfg
+	if sd.pendingCfg != nil {
+		cfg = *sd.pendingCfg
+	}
+	cfg.InputNames = append([]string(nil), cfg.InputNames...)
+	cfg.OutputNames = append([]string(nil), cfg.OutputNames...)
+
+	return cfg
+}
+
+// Stats returns the counters collected since the detector was created or last reset.
+func (sd *Detector) Stats() DetectorStats {
+	return sd.stats
+}
+
+// SetThreshold changes the speech probability threshold. It's safe to call while
+// detection runs on another goroutine.
+func (sd *Detector) SetThreshold(value float32) {
+	sd.updateConfig(func(cfg *DetectorConfig) {
+		cfg.Threshold = value
+		if cfg.TrackNegativeThreshold && cfg.negativeThresholdDerived {
+			cfg.NegativeThreshold = value - negativeThresholdGap
+		}
+	})
+}
+
+// SetNegativeThreshold changes the silence probability threshold. It's safe to call
+// while detection runs on another goroutine.
+func (sd *Detector) SetNegativeThreshold(value float32) {
+	sd.updateConfig(func(cfg *DetectorConfig) {
+		cfg.NegativeThreshold = value
+		cfg.negativeThresholdDerived = false
+	})
+}
+
+// SetMinSpeechDurationMs changes the minimum duration of speech segments. It's safe
+// to call while detection runs on another goroutine.
+func (sd *Detector) SetMinSpeechDurationMs(value int) {
+	sd.updateConfig(func(cfg *DetectorConfig) {
+		cfg.MinSpeechDurationMs = value
+	})
+}
+
+// Reconfigure replaces the detection parameters with those of cfg, validated and with
+// the defaults resolved as by NewDetector. The change is atomic: detection running on
+// another goroutine switches to the whole new config at the next window, rather than
+// observing part of it as with successive setter calls. The settings tied to the model
+// session (ModelPath, LogLevel, UseSharedEnv, LogFilter, OptimizedModelFilePath,
+// ElementType, InputNames, OutputNames, AllocatorType, MemType, ArenaMaxMemory and
+// ArenaInitialChunkSize) are ignored, while SampleRate and HighPassHz must be
+// left unchanged. As with the setters, ResetConfig reverts the change.
+func (sd *Detector) Reconfigure(cfg DetectorConfig) error {
+	if sd == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	if err := cfg.validateParams(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	cfg = cfg.withDefaults()
+
+	sd.cfgMu.Lock()
+	defer sd.cfgMu.Unlock()
+
+	current := sd.cfg
+	if sd.pendingCfg != nil {
+		current = *sd.pendingCfg
+	}
+
+	if cfg.SampleRate != current.SampleRate {
+		return fmt.Errorf("invalid config: invalid SampleRate: should not change from %d", current.SampleRate)
+	}
+	if cfg.HighPassHz != current.HighPassHz {
+		return fmt.Errorf("invalid config: invalid HighPassHz: should not change from %g", current.HighPassHz)
+	}
+
+	cfg.ModelPath = current.ModelPath
+	cfg.LogLevel = current.LogLevel
+	cfg.UseSharedEnv = current.UseSharedEnv
+	cfg.LogFilter = current.LogFilter
+	cfg.OptimizedModelFilePath = current.OptimizedModelFilePath
+	cfg.ElementType = current.ElementType
+	cfg.InputNames = current.InputNames
+	cfg.OutputNames = current.OutputNames
+	cfg.AllocatorType = current.AllocatorType
+	cfg.MemType = current.MemType
+	cfg.ArenaMaxMemory = current.ArenaMaxMemory
+	cfg.ArenaInitialChunkSize = current.ArenaInitialChunkSize
+	sd.pendingCfg = &cfg
+
+	return nil
+}
+
+// updateConfig records a runtime config change, to be applied by the goroutine
+// running detection at the next window.
+func (sd *Detector) updateConfig(update func(cfg *DetectorConfig)) {
+	sd.cfgMu.Lock()
+	defer sd.cfgMu.Unlock()
+
+	if sd.pendingCfg == nil {
+		cfg := sd.cfg
+		sd.pendingCfg = &cfg
+	}
+	update(sd.pendingCfg)
+}
+
+// applyPendingConfig applies the runtime config changes recorded since the last call.
+func (sd *Detector) applyPendingConfig() {
+	sd.cfgMu.Lock()
+	defer sd.cfgMu.Unlock()
+
+	if sd.pendingCfg == nil {
+		return
+	}
+	sd.cfg = *sd.pendingCfg
+	sd.pendingCfg = nil
+}
+
+// ErrDetectorClosed is returned when using a detector after Close or Destroy.
+var ErrDetectorClosed = errors.New("detector closed")
+
+// checkOpen returns ErrDetectorClosed if the detector was closed or destroyed.
+func (sd *Detector) checkOpen() error {
+	sd.closeMu.Lock()
+	defer sd.closeMu.Unlock()
+
+	if sd.closed {
+		return ErrDetectorClosed
+	}
+	return nil
+}
+
+// Close stops the detection running on the detector, if any, and releases its
+// resources. Unlike Destroy, it can be called while detection runs on another
+// goroutine, for example on shutdown: Close waits for the window being processed, after
+// which detection stops with ErrDetectorClosed. Detect, DetectWithTrace and
+// DetectDetailed then return the results collected so far along with the error, the
+// last segment being unfinished if in progress. Calling Close more than once has no
+// effect.
+func (sd *Detector) Close() error {
+	if sd == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	sd.closeMu.Lock()
+	if sd.closed {
+		sd.closeMu.Unlock()
+		return nil
+	}
+	sd.closed = true
+	sd.closeMu.Unlock()
+
+	sd.inferring.Wait()
+
+	return sd.Destroy()
+}
+
+// Destroy releases the resources of the detector. It must not be called while
+// detection runs, see Close for that. Using the detector afterwards fails with
+// ErrDetectorClosed, and calling Destroy more than once has no effect.
+func (sd *Detector) Destroy() error {
+	if sd == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	sd.closeMu.Lock()
+	if sd.destroyed {
+		sd.closeMu.Unlock()
+		return nil
+	}
+	sd.closed = true
+	sd.destroyed = true
+	sd.closeMu.Unlock()
+
+	sd.release()
+
+	return nil
+}
+
+// release frees the resources acquired by the detector, including those of a detector
+// partially created, along with its session, session options and environment when it
+// owns them.
+func (sd *Detector) release() {
+	if sd.memoryInfo != nil {
+		C.OrtApiReleaseMemoryInfo(sd.api, sd.memoryInfo)
+	}
+	if sd.ownsSession {
+		if sd.session != nil {
+			C.OrtApiReleaseSession(sd.api, sd.session)
+		}
+		if sd.sessionOpts != nil {
+			C.OrtApiReleaseSessionOptions(sd.api, sd.sessionOpts)
+		}
+		// Releasing the environment also frees the arena allocator registered on it.
+		if sd.sharedEnv {
+			releaseSharedEnv(sd.api)
+		} else if sd.env != nil {
+			C.OrtApiReleaseEnv(sd.api, sd.env)
+		}
+		if sd.logHandle != 0 {
+			sd.logHandle.Delete()
+		}
+	}
+	for _, ptr := range sd.cStrings {
+		C.free(unsafe.Pointer(ptr))
+	}
 }
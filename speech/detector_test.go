@@ -5,11 +5,41 @@ import (
 	"log/slog"
 	"math"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/skypro1111/silero-vad-go/audioutil"
 )
 
+func readSamplesFromFile(t testing.TB, path string) []float32 {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	samples := make([]float32, 0, len(data)/4)
+	for i := 0; i < len(data); i += 4 {
+		samples = append(samples, math.Float32frombits(binary.LittleEndian.Uint32(data[i:i+4])))
+	}
+	return samples
+}
+
+// segmentTimings returns a copy of segments holding only their timestamps and whether
+// they are finished.
+func segmentTimings(segments []Segment) []Segment {
+	timings := make([]Segment, len(segments))
+	for i, segment := range segments {
+		timings[i] = Segment{
+			SpeechStartAt: segment.SpeechStartAt,
+			SpeechEndAt:   segment.SpeechEndAt,
+			Unfinished:    segment.Unfinished,
+		}
+	}
+	return timings
+}
+
 func TestDetectorConfigIsValid(t *testing.T) {
 	tcs := []struct {
 		name string
@@ -27,10 +57,18 @@ func TestDetectorConfigIsValid(t *testing.T) {
 			name: "invalid SampleRate",
 			cfg: DetectorConfig{
 				ModelPath:  "../testfiles/silero_vad.onnx",
-				SampleRate: 48000,
+				SampleRate: 44100,
 			},
 			err: "invalid SampleRate: valid values are 8000 and 16000",
 		},
+		{
+			name: "unsupported 48000 SampleRate",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 48000,
+			},
+			err: "invalid SampleRate: 48000 is unsupported, resample the audio to 16000 (e.g. with audioutil.Resample) and set SampleRate to 16000",
+		},
 		{
 			name: "invalid Threshold",
 			cfg: DetectorConfig{
@@ -63,13 +101,264 @@ func TestDetectorConfigIsValid(t *testing.T) {
 		{
 			name: "invalid MinSpeechDurationMs",
 			cfg: DetectorConfig{
-				ModelPath:          "../testfiles/silero_vad.onnx",
-				SampleRate:         16000,
-				Threshold:          0.5,
+				ModelPath:           "../testfiles/silero_vad.onnx",
+				SampleRate:          16000,
+				Threshold:           0.5,
 				MinSpeechDurationMs: -1,
 			},
 			err: "invalid MinSpeechDurationMs: should be a positive number",
 		},
+		{
+			name: "invalid InputScale",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				InputScale: -1,
+			},
+			err: "invalid InputScale: should be a positive number",
+		},
+		{
+			name: "invalid StartOffsetSec",
+			cfg: DetectorConfig{
+				ModelPath:      "../testfiles/silero_vad.onnx",
+				SampleRate:     16000,
+				Threshold:      0.5,
+				StartOffsetSec: -1,
+			},
+			err: "invalid StartOffsetSec: should be a positive number",
+		},
+		{
+			name: "invalid HighPassHz",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				HighPassHz: -80,
+			},
+			err: "invalid HighPassHz: should be a positive number",
+		},
+		{
+			name: "invalid HighPassHz above Nyquist",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 8000,
+				Threshold:  0.5,
+				HighPassHz: 4000,
+			},
+			err: "invalid HighPassHz: should be less than half the SampleRate",
+		},
+		{
+			name: "invalid EnvelopeResolutionMs",
+			cfg: DetectorConfig{
+				ModelPath:            "../testfiles/silero_vad.onnx",
+				SampleRate:           16000,
+				Threshold:            0.5,
+				EnvelopeResolutionMs: -10,
+			},
+			err: "invalid EnvelopeResolutionMs: should be a positive number",
+		},
+		{
+			name: "invalid HeaderBytes",
+			cfg: DetectorConfig{
+				ModelPath:   "../testfiles/silero_vad.onnx",
+				SampleRate:  16000,
+				Threshold:   0.5,
+				HeaderBytes: -1,
+			},
+			err: "invalid HeaderBytes: should be a positive number",
+		},
+		{
+			name: "invalid ArenaMaxMemory",
+			cfg: DetectorConfig{
+				ModelPath:      "../testfiles/silero_vad.onnx",
+				SampleRate:     16000,
+				Threshold:      0.5,
+				ArenaMaxMemory: -1,
+			},
+			err: "invalid ArenaMaxMemory: should be a positive number",
+		},
+		{
+			name: "invalid ArenaInitialChunkSize",
+			cfg: DetectorConfig{
+				ModelPath:             "../testfiles/silero_vad.onnx",
+				SampleRate:            16000,
+				Threshold:             0.5,
+				ArenaInitialChunkSize: -1,
+			},
+			err: "invalid ArenaInitialChunkSize: should be a positive number",
+		},
+		{
+			name: "invalid arena with UseSharedEnv",
+			cfg: DetectorConfig{
+				ModelPath:      "../testfiles/silero_vad.onnx",
+				SampleRate:     16000,
+				Threshold:      0.5,
+				UseSharedEnv:   true,
+				ArenaMaxMemory: 64 << 20,
+			},
+			err: "invalid UseSharedEnv: not supported along with the arena settings",
+		},
+		{
+			name: "invalid MaxHoldMs",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				MaxHoldMs:  -1,
+			},
+			err: "invalid MaxHoldMs: should be a positive number",
+		},
+		{
+			name: "invalid QualityThresholds",
+			cfg: DetectorConfig{
+				ModelPath:         "../testfiles/silero_vad.onnx",
+				SampleRate:        16000,
+				Threshold:         0.5,
+				QualityThresholds: QualityThresholds{HighMeanProbability: 0.5, MediumMeanProbability: 0.8},
+			},
+			err: "invalid QualityThresholds: medium thresholds should not exceed high ones",
+		},
+		{
+			name: "invalid IgnoreFirstMs",
+			cfg: DetectorConfig{
+				ModelPath:     "../testfiles/silero_vad.onnx",
+				SampleRate:    16000,
+				Threshold:     0.5,
+				IgnoreFirstMs: -1,
+			},
+			err: "invalid IgnoreFirstMs: should be a positive number",
+		},
+		{
+			name: "invalid IgnoreLastMs",
+			cfg: DetectorConfig{
+				ModelPath:    "../testfiles/silero_vad.onnx",
+				SampleRate:   16000,
+				Threshold:    0.5,
+				IgnoreLastMs: -1,
+			},
+			err: "invalid IgnoreLastMs: should be a positive number",
+		},
+		{
+			name: "invalid FrameAlignMs",
+			cfg: DetectorConfig{
+				ModelPath:    "../testfiles/silero_vad.onnx",
+				SampleRate:   16000,
+				Threshold:    0.5,
+				FrameAlignMs: -10,
+			},
+			err: "invalid FrameAlignMs: should be a positive number",
+		},
+		{
+			name: "invalid MinWindowsForContext",
+			cfg: DetectorConfig{
+				ModelPath:            "../testfiles/silero_vad.onnx",
+				SampleRate:           16000,
+				Threshold:            0.5,
+				MinWindowsForContext: -1,
+			},
+			err: "invalid MinWindowsForContext: should be a positive number",
+		},
+		{
+			name: "invalid InputNames count",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				InputNames: []string{"input", "state"},
+			},
+			err: "invalid InputNames: should contain 3 names",
+		},
+		{
+			name: "invalid OutputNames empty name",
+			cfg: DetectorConfig{
+				ModelPath:   "../testfiles/silero_vad.onnx",
+				SampleRate:  16000,
+				Threshold:   0.5,
+				OutputNames: []string{"output", ""},
+			},
+			err: "invalid OutputNames: names should not be empty",
+		},
+		{
+			name: "invalid TriggerWindows",
+			cfg: DetectorConfig{
+				ModelPath:      "../testfiles/silero_vad.onnx",
+				SampleRate:     16000,
+				Threshold:      0.5,
+				TriggerWindows: -1,
+			},
+			err: "invalid TriggerWindows: should be a positive number",
+		},
+		{
+			name: "invalid OnsetBackoffWindows",
+			cfg: DetectorConfig{
+				ModelPath:           "../testfiles/silero_vad.onnx",
+				SampleRate:          16000,
+				Threshold:           0.5,
+				OnsetBackoffWindows: -1,
+			},
+			err: "invalid OnsetBackoffWindows: should be a positive number",
+		},
+		{
+			name: "invalid ProbSmoothingWindows",
+			cfg: DetectorConfig{
+				ModelPath:            "../testfiles/silero_vad.onnx",
+				SampleRate:           16000,
+				Threshold:            0.5,
+				ProbSmoothingWindows: -1,
+			},
+			err: "invalid ProbSmoothingWindows: should be a positive number",
+		},
+		{
+			name: "invalid ElementType",
+			cfg: DetectorConfig{
+				ModelPath:   "../testfiles/silero_vad.onnx",
+				SampleRate:  16000,
+				Threshold:   0.5,
+				ElementType: TensorElementType(42),
+			},
+			err: "invalid ElementType: valid values are TensorElementTypeFloat32 and TensorElementTypeFloat16",
+		},
+		{
+			name: "invalid MidRangePolicy",
+			cfg: DetectorConfig{
+				ModelPath:      "../testfiles/silero_vad.onnx",
+				SampleRate:     16000,
+				Threshold:      0.5,
+				MidRangePolicy: MidRangePolicy(42),
+			},
+			err: "invalid MidRangePolicy: valid values are MidRangePolicyHold, MidRangePolicyTreatAsSilence and MidRangePolicyTreatAsSpeech",
+		},
+		{
+			name: "invalid AllocatorType",
+			cfg: DetectorConfig{
+				ModelPath:     "../testfiles/silero_vad.onnx",
+				SampleRate:    16000,
+				Threshold:     0.5,
+				AllocatorType: AllocatorType(42),
+			},
+			err: "invalid AllocatorType: valid values are AllocatorTypeArena and AllocatorTypeDevice",
+		},
+		{
+			name: "invalid MemType",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				MemType:    MemType(42),
+			},
+			err: "invalid MemType: valid values are MemTypeDefault, MemTypeCPUInput and MemTypeCPUOutput",
+		},
+		{
+			name: "invalid OutputType",
+			cfg: DetectorConfig{
+				ModelPath:  "../testfiles/silero_vad.onnx",
+				SampleRate: 16000,
+				Threshold:  0.5,
+				OutputType: OutputType(42),
+			},
+			err: "invalid OutputType: valid values are OutputTypeProbability and OutputTypeLogit",
+		},
 		{
 			name: "invalid NegativeThreshold range",
 			cfg: DetectorConfig{
@@ -90,6 +379,16 @@ func TestDetectorConfigIsValid(t *testing.T) {
 			},
 			err: "invalid NegativeThreshold: should be less than Threshold",
 		},
+		{
+			name: "valid custom tensor names",
+			cfg: DetectorConfig{
+				ModelPath:   "../testfiles/silero_vad.onnx",
+				SampleRate:  16000,
+				Threshold:   0.5,
+				InputNames:  []string{"audio", "h", "rate"},
+				OutputNames: []string{"prob", "hn"},
+			},
+		},
 		{
 			name: "valid",
 			cfg: DetectorConfig{
@@ -128,115 +427,294 @@ func TestNewDetector(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestSpeechDetection(t *testing.T) {
+func TestSharedEnv(t *testing.T) {
 	cfg := DetectorConfig{
-		ModelPath:  "../testfiles/silero_vad.onnx",
-		SampleRate: 16000,
-		Threshold:  0.5,
+		ModelPath:    "../testfiles/silero_vad.onnx",
+		SampleRate:   16000,
+		Threshold:    0.5,
+		UseSharedEnv: true,
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		AddSource: true,
-		Level:     slog.LevelDebug,
-	}))
-	slog.SetDefault(logger)
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
 
-	sd, err := NewDetector(cfg)
+	sd1, err := NewDetector(cfg)
 	require.NoError(t, err)
-	require.NotNil(t, sd)
+	require.NotNil(t, sd1)
+
+	sd2, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd2)
+	require.Equal(t, sd1.env, sd2.env)
+	require.Equal(t, 2, sharedEnv.refs)
+
+	// A detector failing to be created doesn't hold a reference.
+	badCfg := cfg
+	badCfg.ModelPath = "../testfiles/missing.onnx"
+	_, err = NewDetector(badCfg)
+	require.Error(t, err)
+	require.Equal(t, 2, sharedEnv.refs)
+
+	// Destroying a detector keeps the environment alive for the other.
+	require.NoError(t, sd1.Destroy())
+	require.Equal(t, 1, sharedEnv.refs)
+	_, err = sd2.Detect(samples)
+	require.NoError(t, err)
+
+	require.NoError(t, sd2.Destroy())
+	require.Zero(t, sharedEnv.refs)
+	require.Nil(t, sharedEnv.env)
+
+	// Detectors not using the shared environment have their own.
+	cfg.UseSharedEnv = false
+	sd3, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd3)
 	defer func() {
-		require.NoError(t, sd.Destroy())
+		require.NoError(t, sd3.Destroy())
 	}()
+	require.Nil(t, sharedEnv.env)
+}
 
-	readSamplesFromFile := func(path string) []float32 {
-		data, err := os.ReadFile(path)
-		require.NoError(t, err)
-
-		samples := make([]float32, 0, len(data)/4)
-		for i := 0; i < len(data); i += 4 {
-			samples = append(samples, math.Float32frombits(binary.LittleEndian.Uint32(data[i:i+4])))
-		}
-		return samples
+func TestNewDetectorFromSession(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
 	}
 
-	samples := readSamplesFromFile("../testfiles/samples.pcm")
-	samples2 := readSamplesFromFile("../testfiles/samples2.pcm")
-
-	t.Run("detect", func(t *testing.T) {
-		segments, err := sd.Detect(samples)
-		require.NoError(t, err)
-		require.NotEmpty(t, segments)
-		require.Equal(t, []Segment{
-			{
-				SpeechStartAt: 1.056,
-				SpeechEndAt:   1.632,
-			},
-			{
-				SpeechStartAt: 2.88,
-				SpeechEndAt:   3.232,
-			},
-			{
-				SpeechStartAt: 4.448,
-				SpeechEndAt:   0,
-			},
-		}, segments)
+	t.Run("nil session", func(t *testing.T) {
+		sd, err := NewDetectorFromSession(nil, cfg)
+		require.EqualError(t, err, "invalid nil session")
+		require.Nil(t, sd)
+	})
 
-		err = sd.Reset()
+	t.Run("shared session", func(t *testing.T) {
+		owner, err := NewDetector(cfg)
 		require.NoError(t, err)
+		require.NotNil(t, owner)
+		defer func() {
+			require.NoError(t, owner.Destroy())
+		}()
 
-		segments, err = sd.Detect(samples2)
+		// ModelPath is not needed when reusing a session.
+		sd, err := NewDetectorFromSession(owner.Session(), DetectorConfig{
+			SampleRate: 16000,
+			Threshold:  0.5,
+		})
 		require.NoError(t, err)
-		require.NotEmpty(t, segments)
-		require.Equal(t, []Segment{
-			{
-				SpeechStartAt: 3.008,
-				SpeechEndAt:   6.24,
-			},
-			{
-				SpeechStartAt: 7.072,
-				SpeechEndAt:   8.16,
-			},
-		}, segments)
-	})
+		require.NotNil(t, sd)
 
-	t.Run("reset", func(t *testing.T) {
-		err = sd.Reset()
+		pcm := make([]float32, 16000)
+		_, err = sd.Detect(pcm)
 		require.NoError(t, err)
 
-		segments, err := sd.Detect(samples)
+		// Destroying the borrowing detector must leave the shared session usable.
+		require.NoError(t, sd.Destroy())
+		_, err = owner.Detect(pcm)
 		require.NoError(t, err)
-		require.NotEmpty(t, segments)
-		require.Equal(t, []Segment{
-			{
-				SpeechStartAt: 1.056,
-				SpeechEndAt:   1.632,
-			},
-			{
-				SpeechStartAt: 2.88,
-				SpeechEndAt:   3.232,
-			},
-			{
-				SpeechStartAt: 4.448,
-				SpeechEndAt:   0,
-			},
-		}, segments)
 	})
+}
 
-	t.Run("speech padding", func(t *testing.T) {
-		cfg.SpeechPadMs = 10
-		sd, err := NewDetector(cfg)
-		require.NoError(t, err)
-		require.NotNil(t, sd)
-		defer func() {
-			require.NoError(t, sd.Destroy())
-		}()
+func TestSupportedSampleRates(t *testing.T) {
+	rates := SupportedSampleRates()
+	require.Equal(t, []int{8000, 16000}, rates)
 
-		segments, err := sd.Detect(samples)
-		require.NoError(t, err)
-		require.NotEmpty(t, segments)
-		require.Equal(t, []Segment{
-			{
-				SpeechStartAt: 1.056 - 0.01,
+	for _, rate := range rates {
+		cfg := DetectorConfig{
+			ModelPath:  "../testfiles/silero_vad.onnx",
+			SampleRate: rate,
+			Threshold:  0.5,
+		}
+		require.NoError(t, cfg.IsValid())
+	}
+
+	// The returned slice is a copy.
+	rates[0] = 44100
+	require.Equal(t, []int{8000, 16000}, SupportedSampleRates())
+}
+
+func TestSampleRateInputType(t *testing.T) {
+	sd, err := NewDetector(DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	// The bundled model takes the sample rate as int64.
+	require.False(t, sd.floatRate)
+}
+
+func TestCheckBatchDim(t *testing.T) {
+	tcs := []struct {
+		name  string
+		shape []int64
+		axis  int
+		err   string
+	}{
+		{name: "fixed audio", shape: []int64{1, 576}, axis: 0},
+		{name: "dynamic audio", shape: []int64{-1, -1}, axis: 0},
+		{name: "dynamic state", shape: []int64{2, -1, 128}, axis: 1},
+		{name: "unknown rank", shape: nil, axis: 1},
+		{
+			name:  "fixed batch",
+			shape: []int64{2, 4, 128},
+			axis:  1,
+			err:   `unsupported model input "x": batch dimension is 4, should be 1 or dynamic`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkBatchDim("x", tc.shape, tc.axis)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestCheckBridgeVersion(t *testing.T) {
+	require.NoError(t, checkBridgeVersion())
+}
+
+func TestHasSplitState(t *testing.T) {
+	tcs := []struct {
+		name   string
+		inputs []string
+		split  bool
+	}{
+		{name: "v5", inputs: []string{"input", "state", "sr"}, split: false},
+		{name: "v4", inputs: []string{"input", "sr", "h", "c"}, split: true},
+		{name: "partial", inputs: []string{"input", "sr", "h"}, split: false},
+		{name: "empty", inputs: nil, split: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.split, hasSplitState(tc.inputs))
+		})
+	}
+
+	sd, err := NewDetector(DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+	require.False(t, sd.splitState)
+}
+
+func TestSpeechDetection(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+	}))
+	slog.SetDefault(logger)
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+	samples2 := readSamplesFromFile(t, "../testfiles/samples2.pcm")
+
+	t.Run("detect", func(t *testing.T) {
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		require.Equal(t, []Segment{
+			{
+				SpeechStartAt: 1.056,
+				SpeechEndAt:   1.632,
+			},
+			{
+				SpeechStartAt: 2.88,
+				SpeechEndAt:   3.232,
+			},
+			{
+				SpeechStartAt: 4.448,
+				SpeechEndAt:   0,
+				Unfinished:    true,
+			},
+		}, segmentTimings(segments))
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		segments, err = sd.Detect(samples2)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		require.Equal(t, []Segment{
+			{
+				SpeechStartAt: 3.008,
+				SpeechEndAt:   6.24,
+			},
+			{
+				SpeechStartAt: 7.072,
+				SpeechEndAt:   8.16,
+			},
+		}, segmentTimings(segments))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		require.Equal(t, []Segment{
+			{
+				SpeechStartAt: 1.056,
+				SpeechEndAt:   1.632,
+			},
+			{
+				SpeechStartAt: 2.88,
+				SpeechEndAt:   3.232,
+			},
+			{
+				SpeechStartAt: 4.448,
+				SpeechEndAt:   0,
+				Unfinished:    true,
+			},
+		}, segmentTimings(segments))
+	})
+
+	t.Run("speech padding", func(t *testing.T) {
+		cfg.SpeechPadMs = 10
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		require.Equal(t, []Segment{
+			{
+				SpeechStartAt: 1.056 - 0.01,
 				SpeechEndAt:   1.632 + 0.01,
 			},
 			{
@@ -246,8 +724,9 @@ func TestSpeechDetection(t *testing.T) {
 			{
 				SpeechStartAt: 4.448 - 0.01,
 				SpeechEndAt:   0,
+				Unfinished:    true,
 			},
-		}, segments)
+		}, segmentTimings(segments))
 	})
 
 	t.Run("negative threshold", func(t *testing.T) {
@@ -268,14 +747,14 @@ func TestSpeechDetection(t *testing.T) {
 		sd.SetNegativeThreshold(0.2)
 		err = sd.Reset()
 		require.NoError(t, err)
-		
+
 		segments2, err := sd.Detect(samples)
 		require.NoError(t, err)
 		require.NotEmpty(t, segments2)
-		
+
 		// With an even lower threshold, we expect longer speech segments
 		// or potentially fewer segments due to merging
-		require.True(t, len(segments2) <= len(segments), 
+		require.True(t, len(segments2) <= len(segments),
 			"Expected fewer or equal number of segments with lower threshold")
 	})
 
@@ -283,7 +762,7 @@ func TestSpeechDetection(t *testing.T) {
 		// Reset config
 		cfg.SpeechPadMs = 0
 		cfg.NegativeThreshold = 0
-		
+
 		// First run with no minimum speech duration
 		cfg.MinSpeechDurationMs = 0
 		sd, err := NewDetector(cfg)
@@ -302,12 +781,1817 @@ func TestSpeechDetection(t *testing.T) {
 		sd.SetMinSpeechDurationMs(1000) // 1 second
 		err = sd.Reset()
 		require.NoError(t, err)
-		
+
 		segments2, err := sd.Detect(samples)
 		require.NoError(t, err)
-		
+
 		// With a higher minimum speech duration, we expect fewer segments
-		require.True(t, len(segments2) <= initialSegmentCount, 
+		require.True(t, len(segments2) <= initialSegmentCount,
 			"Expected fewer segments with higher minimum speech duration")
 	})
+
+	t.Run("pad short input", func(t *testing.T) {
+		cfg.MinSpeechDurationMs = 0
+		cfg.PadShortInput = true
+		defer func() {
+			cfg.PadShortInput = false
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// Inputs shorter than a single window are padded rather than rejected.
+		_, err = sd.Detect(samples[:100])
+		require.NoError(t, err)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		// Half a second of audio starting right before the first utterance.
+		segments, err := sd.Detect(samples[16000:24000])
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		for _, segment := range segments {
+			require.GreaterOrEqual(t, segment.SpeechStartAt, 0.0)
+			require.LessOrEqual(t, segment.SpeechEndAt, 0.5)
+		}
+	})
+
+	t.Run("input scale", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+
+		// Samples in int16 range scaled back down by the detector should yield the same result.
+		intRangeSamples := make([]float32, len(samples))
+		for i := range samples {
+			intRangeSamples[i] = samples[i] * 32768
+		}
+
+		cfg.InputScale = 1.0 / 32768
+		defer func() {
+			cfg.InputScale = 0
+		}()
+		sd2, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd2)
+		defer func() {
+			require.NoError(t, sd2.Destroy())
+		}()
+
+		segments, err := sd2.Detect(intRangeSamples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		cfg.SkipErrorWindows = true
+		defer func() {
+			cfg.SkipErrorWindows = false
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, DetectorStats{
+			Windows: (len(samples) - 1) / 512,
+		}, sd.Stats())
+
+		err = sd.Reset()
+		require.NoError(t, err)
+		require.Equal(t, DetectorStats{}, sd.Stats())
+	})
+
+	t.Run("detect bytes", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile("../testfiles/samples.pcm")
+		require.NoError(t, err)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		segments, err := sd.DetectBytes(data, SampleFormatFloat32LE)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		_, err = sd.DetectBytes(data[:len(data)-1], SampleFormatFloat32LE)
+		require.EqualError(t, err, "failed to decode samples: invalid data length: should be a multiple of 4")
+	})
+
+	t.Run("detect interleaved int16", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// Both channels are offset from the int16 samples by opposite amounts, so that
+		// their average is the original signal.
+		mono := make([]float32, len(samples))
+		stereo := make([]int16, 2*len(samples))
+		for i, s := range samples {
+			v := int16(max(min(s*32768, 32767-100), -32768+100))
+			mono[i] = float32(v) / 32768
+			stereo[2*i] = v - 100
+			stereo[2*i+1] = v + 100
+		}
+
+		expected, err := sd.Detect(mono)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		segments, err := sd.DetectInterleavedInt16(stereo, 2)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// The buffer is reused across calls.
+		buf := &sd.monoBuf[0]
+		require.NoError(t, sd.Reset())
+		_, err = sd.DetectInterleavedInt16(stereo[:2*(len(samples)/2)], 2)
+		require.NoError(t, err)
+		require.Same(t, buf, &sd.monoBuf[0])
+
+		_, err = sd.DetectInterleavedInt16(stereo[:3], 2)
+		require.EqualError(t, err, "invalid data length: should be a multiple of 2")
+
+		_, err = sd.DetectInterleavedInt16(stereo, 0)
+		require.EqualError(t, err, "invalid channels: should be a positive number")
+	})
+
+	t.Run("speech ratio", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		ratio, err := sd.SpeechRatio(samples)
+		require.NoError(t, err)
+		require.Greater(t, ratio, 0.0)
+		require.Less(t, ratio, 1.0)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		ratio, err = sd.SpeechRatio(make([]float32, 16000))
+		require.NoError(t, err)
+		require.Zero(t, ratio)
+
+		_, err = sd.SpeechRatio(samples[:100])
+		require.EqualError(t, err, "not enough samples")
+	})
+
+	t.Run("reset config", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		initialCfg := sd.cfg
+
+		sd.SetThreshold(0.8)
+		sd.SetNegativeThreshold(0.6)
+		sd.SetMinSpeechDurationMs(1000)
+		// Changes are applied by the detection goroutine at the next window.
+		require.Equal(t, initialCfg, sd.cfg)
+		sd.applyPendingConfig()
+		require.NotEqual(t, initialCfg, sd.cfg)
+		require.Equal(t, float32(0.8), sd.cfg.Threshold)
+
+		err = sd.ResetConfig()
+		require.NoError(t, err)
+		sd.applyPendingConfig()
+		require.Equal(t, initialCfg, sd.cfg)
+		require.Equal(t, float32(0.35), sd.cfg.NegativeThreshold)
+	})
+
+	t.Run("start offset", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+		for i := range expected {
+			expected[i].SpeechStartAt += 10
+			if !expected[i].Unfinished {
+				expected[i].SpeechEndAt += 10
+			}
+		}
+
+		cfg.StartOffsetSec = 10
+		defer func() {
+			cfg.StartOffsetSec = 0
+		}()
+		sd2, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd2)
+		defer func() {
+			require.NoError(t, sd2.Destroy())
+		}()
+
+		segments, err := sd2.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("min windows for context", func(t *testing.T) {
+		cfg.MinWindowsForContext = 4
+		defer func() {
+			cfg.MinWindowsForContext = 0
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, err = sd.Detect(samples[:3*512])
+		require.EqualError(t, err, "not enough samples: at least 4 windows are needed for context")
+
+		_, err = sd.Detect(samples[:4*512])
+		require.NoError(t, err)
+	})
+
+	t.Run("voiced windows", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		for _, segment := range segments {
+			// The triggering window is always voiced.
+			require.GreaterOrEqual(t, segment.VoicedWindows, 1)
+			if !segment.Unfinished {
+				windows := int(math.Round((segment.SpeechEndAt - segment.SpeechStartAt) * 16000 / 512))
+				require.LessOrEqual(t, segment.VoicedWindows, windows)
+			}
+		}
+	})
+
+	t.Run("latency stats", func(t *testing.T) {
+		cfg.CollectLatency = true
+		defer func() {
+			cfg.CollectLatency = false
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		require.Equal(t, LatencyStats{}, sd.LatencyStats())
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+
+		stats := sd.LatencyStats()
+		require.Equal(t, sd.Stats().Windows, stats.Count)
+		require.LessOrEqual(t, stats.Min, stats.P50)
+		require.LessOrEqual(t, stats.P50, stats.P95)
+		require.LessOrEqual(t, stats.P95, stats.Max)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+		require.Equal(t, LatencyStats{}, sd.LatencyStats())
+	})
+
+	t.Run("refine boundaries", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+
+		cfg.RefineBoundaries = true
+		defer func() {
+			cfg.RefineBoundaries = false
+		}()
+		sd2, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd2)
+		defer func() {
+			require.NoError(t, sd2.Destroy())
+		}()
+
+		segments, err := sd2.Detect(samples)
+		require.NoError(t, err)
+		require.Len(t, segments, len(expected))
+
+		// Refined boundaries can only move within the windows surrounding the crossing.
+		windowSec := 512.0 / 16000
+		for i := range segments {
+			require.InDelta(t, expected[i].SpeechStartAt, segments[i].SpeechStartAt, windowSec/2)
+			if expected[i].Unfinished {
+				require.Zero(t, segments[i].SpeechEndAt)
+				continue
+			}
+			require.InDelta(t, expected[i].SpeechEndAt-windowSec, segments[i].SpeechEndAt, windowSec/2)
+		}
+	})
+
+	t.Run("unfinished segments", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+
+		// Only the last segment can still be in progress at the end of the input.
+		for _, segment := range segments[:len(segments)-1] {
+			require.False(t, segment.Unfinished)
+			require.NotZero(t, segment.SpeechEndAt)
+		}
+		last := segments[len(segments)-1]
+		require.Equal(t, last.Unfinished, last.SpeechEndAt == 0)
+	})
+
+	t.Run("concurrent setters", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				sd.SetThreshold(0.5 + float32(i%10)/100)
+				sd.SetNegativeThreshold(0.3)
+				sd.SetMinSpeechDurationMs(250)
+			}
+		}()
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		<-done
+
+		// Changes made once detection is over apply to the next run.
+		sd.SetThreshold(0.7)
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, float32(0.7), sd.cfg.Threshold)
+	})
+
+	t.Run("buffers kept across reset", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		inputBuf := &sd.inputBuf[:1][0]
+		inputValues := &sd.inputValues[:1][0]
+		outputValues := &sd.outputValues[0]
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		require.Same(t, inputBuf, &sd.inputBuf[:1][0])
+		require.Same(t, inputValues, &sd.inputValues[:1][0])
+		require.Same(t, outputValues, &sd.outputValues[0])
+	})
+
+	t.Run("has speech", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		found, err := sd.HasSpeech(samples)
+		require.NoError(t, err)
+		require.True(t, found)
+		// Processing stops at the first speech window.
+		require.Less(t, sd.Stats().Windows, len(samples)/512)
+		require.LessOrEqual(t, float64(sd.Stats().Windows*512)/16000, segments[0].SpeechStartAt+512.0/16000)
+
+		err = sd.Reset()
+		require.NoError(t, err)
+
+		found, err = sd.HasSpeech(make([]float32, 16000))
+		require.NoError(t, err)
+		require.False(t, found)
+
+		_, err = sd.HasSpeech(samples[:100])
+		require.EqualError(t, err, "not enough samples")
+	})
+
+	t.Run("has speech min duration", func(t *testing.T) {
+		cfg.HasSpeechMinDuration = true
+		cfg.MinSpeechDurationMs = 60000
+		defer func() {
+			cfg.HasSpeechMinDuration = false
+			cfg.MinSpeechDurationMs = 0
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// No speech lasts for a whole minute.
+		found, err := sd.HasSpeech(samples)
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("trigger windows", func(t *testing.T) {
+		cfg.TriggerWindows = 3
+		defer func() {
+			cfg.TriggerWindows = 0
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, trace, err := sd.DetectWithTrace(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+
+		var starts []float64
+		for i, decision := range trace {
+			if !decision.TriggerChanged || !decision.Triggered {
+				continue
+			}
+			// Triggering takes three consecutive speech windows, the segment
+			// starting at the first of them.
+			require.GreaterOrEqual(t, i, 2)
+			for _, d := range trace[i-2 : i+1] {
+				require.GreaterOrEqual(t, d.Probability, cfg.Threshold)
+			}
+			require.False(t, trace[i-1].Triggered)
+			starts = append(starts, float64(decision.Sample-3*512)/16000)
+		}
+
+		for _, segment := range segments {
+			require.Contains(t, starts, segment.SpeechStartAt)
+			require.GreaterOrEqual(t, segment.VoicedWindows, 3)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		resolved := sd.Config()
+		require.Equal(t, cfg.ModelPath, resolved.ModelPath)
+		require.Equal(t, float32(0.5), resolved.Threshold)
+		require.Equal(t, float32(0.35), resolved.NegativeThreshold)
+		require.Equal(t, 250, resolved.MinSpeechDurationMs)
+		require.Equal(t, []string{"input", "state", "sr"}, resolved.InputNames)
+
+		// The returned config is a copy.
+		resolved.InputNames[0] = "other"
+		require.Equal(t, "input", sd.Config().InputNames[0])
+
+		// Runtime changes are reflected right away.
+		sd.SetThreshold(0.7)
+		require.Equal(t, float32(0.7), sd.Config().Threshold)
+
+		err = sd.ResetConfig()
+		require.NoError(t, err)
+		require.Equal(t, float32(0.5), sd.Config().Threshold)
+	})
+
+	t.Run("track negative threshold", func(t *testing.T) {
+		cfg.TrackNegativeThreshold = true
+		defer func() {
+			cfg.TrackNegativeThreshold = false
+		}()
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// The derived negative threshold follows the threshold.
+		sd.SetThreshold(0.7)
+		require.InDelta(t, 0.55, sd.Config().NegativeThreshold, 1e-6)
+
+		// Until it's set explicitly.
+		sd.SetNegativeThreshold(0.3)
+		sd.SetThreshold(0.6)
+		require.Equal(t, float32(0.3), sd.Config().NegativeThreshold)
+
+		// Reverting the config makes it derived again.
+		require.NoError(t, sd.ResetConfig())
+		sd.SetThreshold(0.8)
+		require.InDelta(t, 0.65, sd.Config().NegativeThreshold, 1e-6)
+
+		// An explicit negative threshold in the config is never changed.
+		cfg.NegativeThreshold = 0.2
+		defer func() {
+			cfg.NegativeThreshold = 0
+		}()
+		sd2, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd2)
+		defer func() {
+			require.NoError(t, sd2.Destroy())
+		}()
+
+		sd2.SetThreshold(0.7)
+		require.Equal(t, float32(0.2), sd2.Config().NegativeThreshold)
+	})
+
+	t.Run("negative threshold not tracked by default", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		sd.SetThreshold(0.7)
+		require.Equal(t, float32(0.35), sd.Config().NegativeThreshold)
+	})
+
+	t.Run("prob smoothing", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, raw, err := sd.DetectWithTrace(samples)
+		require.NoError(t, err)
+
+		cfg.ProbSmoothingWindows = 3
+		defer func() {
+			cfg.ProbSmoothingWindows = 0
+		}()
+		sd2, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd2)
+		defer func() {
+			require.NoError(t, sd2.Destroy())
+		}()
+
+		_, smoothed, err := sd2.DetectWithTrace(samples)
+		require.NoError(t, err)
+		require.Len(t, smoothed, len(raw))
+
+		for i := range smoothed {
+			var sum float32
+			window := raw[max(i-2, 0) : i+1]
+			for _, d := range window {
+				sum += d.Probability
+			}
+			require.InDelta(t, sum/float32(len(window)), smoothed[i].Probability, 1e-6)
+		}
+
+		// Smoothing starts over after a reset.
+		require.NoError(t, sd2.Reset())
+		_, smoothed, err = sd2.DetectWithTrace(samples)
+		require.NoError(t, err)
+		require.Equal(t, raw[0].Probability, smoothed[0].Probability)
+	})
+
+	t.Run("unexpected speech end", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+		require.False(t, segments[0].Unfinished)
+
+		// Split the input within the first segment so that it ends in the second call.
+		split := int((segments[0].SpeechStartAt+segments[0].SpeechEndAt)/2*16000) / 512 * 512
+		require.NoError(t, sd.Reset())
+		_, err = sd.Detect(samples[:split+512])
+		require.NoError(t, err)
+
+		_, err = sd.Detect(samples[split+512:])
+		require.Error(t, err)
+		require.Regexp(t, `^unexpected speech end: 0 segments collected, at sample \d+ \(\d+\.\d{3}s\)$`, err.Error())
+	})
+
+	t.Run("mid range policy", func(t *testing.T) {
+		// A speech window, followed by a silence one, mid-range ones and silence again.
+		probs := []float32{0.9, 0.2, 0.4, 0.4, 0.4, 0.4, 0.4, 0.4, 0.2, 0.2, 0.2}
+
+		tcs := []struct {
+			name   string
+			policy MidRangePolicy
+			events map[int]speechEvent
+		}{
+			{
+				name:   "default",
+				events: map[int]speechEvent{0: speechEventStart, 8: speechEventEnd},
+			},
+			{
+				name:   "hold",
+				policy: MidRangePolicyHold,
+				events: map[int]speechEvent{0: speechEventStart, 8: speechEventEnd},
+			},
+			{
+				name:   "treat as silence",
+				policy: MidRangePolicyTreatAsSilence,
+				events: map[int]speechEvent{0: speechEventStart, 5: speechEventEnd},
+			},
+			{
+				name:   "treat as speech",
+				policy: MidRangePolicyTreatAsSpeech,
+				events: map[int]speechEvent{0: speechEventStart},
+			},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				cfg := cfg
+				cfg.MinSilenceDurationMs = 100
+				cfg.MidRangePolicy = tc.policy
+				sd, err := NewDetector(cfg)
+				require.NoError(t, err)
+				require.NotNil(t, sd)
+				defer func() {
+					require.NoError(t, sd.Destroy())
+				}()
+
+				events := map[int]speechEvent{}
+				for i, prob := range probs {
+					sd.currSample += sd.windowSize()
+					if event, _ := sd.step(prob); event != speechEventNone {
+						events[i] = event
+					}
+				}
+				require.Equal(t, tc.events, events)
+			})
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+
+		var long []float32
+		for i := 0; i < 20; i++ {
+			long = append(long, samples...)
+		}
+
+		type result struct {
+			segments []Segment
+			err      error
+		}
+		done := make(chan result)
+		go func() {
+			segments, err := sd.Detect(long)
+			done <- result{segments, err}
+		}()
+
+		require.NoError(t, sd.Close())
+		res := <-done
+		if res.err != nil {
+			// Detection was stopped midway.
+			require.ErrorIs(t, res.err, ErrDetectorClosed)
+			for _, segment := range res.segments[:max(len(res.segments)-1, 0)] {
+				require.False(t, segment.Unfinished)
+			}
+		}
+
+		_, err = sd.Detect(samples)
+		require.ErrorIs(t, err, ErrDetectorClosed)
+
+		// Closing again has no effect.
+		require.NoError(t, sd.Close())
+	})
+
+	t.Run("use after destroy", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		require.NoError(t, sd.Destroy())
+
+		_, err = sd.Detect(samples)
+		require.ErrorIs(t, err, ErrDetectorClosed)
+		_, _, err = sd.DetectDetailed(samples)
+		require.ErrorIs(t, err, ErrDetectorClosed)
+		_, err = sd.SpeechRatio(samples)
+		require.ErrorIs(t, err, ErrDetectorClosed)
+		_, err = sd.ModelMetadata()
+		require.ErrorIs(t, err, ErrDetectorClosed)
+		require.ErrorIs(t, sd.Reset(), ErrDetectorClosed)
+
+		// Destroying or closing again has no effect.
+		require.NoError(t, sd.Destroy())
+		require.NoError(t, sd.Close())
+	})
+
+	t.Run("close open segments", func(t *testing.T) {
+		cfg := cfg
+		cfg.MinSilenceDurationMs = 500
+		cfg.SpeechPadMs = 30
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, trace, err := sd.DetectWithTrace(samples)
+		require.NoError(t, err)
+
+		// Cut the input within the silence following a segment.
+		cut := -1
+		for i, decision := range trace {
+			if decision.Triggered && decision.TempEnd != 0 {
+				cut = i
+				break
+			}
+		}
+		require.NotEqual(t, -1, cut)
+		pcm := samples[:(cut+1)*512+1]
+
+		err = sd.Reset()
+		require.NoError(t, err)
+		open, err := sd.Detect(pcm)
+		require.NoError(t, err)
+		require.NotEmpty(t, open)
+		require.True(t, open[len(open)-1].Unfinished)
+
+		sd.cfg.CloseOpenSegments = true
+		err = sd.Reset()
+		require.NoError(t, err)
+		closed, err := sd.Detect(pcm)
+		require.NoError(t, err)
+		require.Len(t, closed, len(open))
+		last := closed[len(closed)-1]
+		require.False(t, last.Unfinished)
+		require.Equal(t, open[len(open)-1].SpeechStartAt, last.SpeechStartAt)
+		require.Equal(t, float64(trace[cut].TempEnd+480)/16000, last.SpeechEndAt)
+
+		// The next input starts anew rather than ending the closed segment.
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+	})
+
+	t.Run("detect mask", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, probs, err := sd.DetectDetailed(samples)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		mask, err := sd.DetectMask(samples)
+		require.NoError(t, err)
+		require.Len(t, mask, len(probs))
+
+		var speech, mid bool
+		for i, prob := range probs {
+			switch {
+			case prob >= 0.5:
+				speech = true
+			case prob < 0.35:
+				speech = false
+			default:
+				mid = true
+			}
+			require.Equal(t, speech, mask[i], "window %d", i)
+		}
+		require.True(t, mid)
+		require.Contains(t, mask, true)
+		require.Contains(t, mask, false)
+
+		var nilDetector *Detector
+		_, err = nilDetector.DetectMask(samples)
+		require.EqualError(t, err, "invalid nil detector")
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		type progress struct{ processed, total int }
+		var calls []progress
+
+		cfg := cfg
+		cfg.OnProgress = func(processedSamples, totalSamples int) {
+			calls = append(calls, progress{processedSamples, totalSamples})
+		}
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+
+		windows := len(samples) / 512
+		require.Len(t, calls, windows/100+1)
+		for i, call := range calls[:len(calls)-1] {
+			require.Equal(t, progress{(i + 1) * 100 * 512, len(samples)}, call)
+		}
+		require.Equal(t, progress{len(samples), len(samples)}, calls[len(calls)-1])
+	})
+
+	t.Run("detect iter", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.Greater(t, len(expected), 1)
+
+		require.NoError(t, sd.Reset())
+		var segments []Segment
+		sd.DetectIter(samples)(func(segment Segment, err error) bool {
+			require.NoError(t, err)
+			segments = append(segments, segment)
+			return true
+		})
+		require.Equal(t, expected, segments)
+
+		// Stopping early.
+		require.NoError(t, sd.Reset())
+		segments = nil
+		sd.DetectIter(samples)(func(segment Segment, err error) bool {
+			require.NoError(t, err)
+			segments = append(segments, segment)
+			return false
+		})
+		require.Equal(t, expected[:1], segments)
+
+		// Failing.
+		var errs []error
+		sd.DetectIter(samples[:100])(func(segment Segment, err error) bool {
+			errs = append(errs, err)
+			return true
+		})
+		require.Len(t, errs, 1)
+		require.Error(t, errs[0])
+	})
+
+	t.Run("early trigger padding", func(t *testing.T) {
+		cfg := cfg
+		cfg.SpeechPadMs = 1000
+		cfg.RefineBoundaries = true
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// Triggering on the first window, the padded start is clamped at zero.
+		sd.currSample += sd.windowSize()
+		event, startAt := sd.step(0.9)
+		require.Equal(t, speechEventStart, event)
+		require.Zero(t, startAt)
+
+		// Ending right away, the end still follows the start.
+		sd.currSample += sd.windowSize()
+		event, endAt := sd.step(0.1)
+		require.Equal(t, speechEventEnd, event)
+		require.Greater(t, endAt, startAt)
+	})
+
+	t.Run("allow negative start", func(t *testing.T) {
+		cfg := cfg
+		cfg.SpeechPadMs = 100
+		cfg.AllowNegativeStart = true
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// Triggering on the first window, the padded start extends before the audio.
+		sd.currSample += sd.windowSize()
+		event, startAt := sd.step(0.9)
+		require.Equal(t, speechEventStart, event)
+		require.InDelta(t, -0.1, startAt, 1e-9)
+
+		// Later segments are unaffected.
+		require.NoError(t, sd.Reset())
+		sd.currSample += 10 * sd.windowSize()
+		event, startAt = sd.step(0.9)
+		require.Equal(t, speechEventStart, event)
+		require.InDelta(t, float64(9*sd.windowSize())/16000-0.1, startAt, 1e-9)
+	})
+
+	t.Run("empty segments", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		sd.SetMinSpeechDurationMs(0)
+		sd.applyPendingConfig()
+		require.True(t, sd.tooShort(Segment{SpeechStartAt: 1, SpeechEndAt: 1}))
+		require.True(t, sd.tooShort(Segment{SpeechStartAt: 1, SpeechEndAt: 0.5}))
+		require.False(t, sd.tooShort(Segment{SpeechStartAt: 1, SpeechEndAt: 1.01}))
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		for _, segment := range expected {
+			require.Nil(t, segment.Envelope)
+		}
+
+		cfg := cfg
+		cfg.EnvelopeResolutionMs = 20
+		sdEnvelope, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sdEnvelope)
+		defer func() {
+			require.NoError(t, sdEnvelope.Destroy())
+		}()
+
+		segments, err := sdEnvelope.Detect(samples)
+		require.NoError(t, err)
+		require.Len(t, segments, len(expected))
+		for i, segment := range segments {
+			end := segment.SpeechEndAt
+			if segment.Unfinished {
+				end = float64(len(samples)) / float64(cfg.SampleRate)
+			}
+			n := int(end*float64(cfg.SampleRate)) - int(segment.SpeechStartAt*float64(cfg.SampleRate))
+			require.Len(t, segment.Envelope, (n+319)/320)
+
+			segment.Envelope = nil
+			require.Equal(t, expected[i], segment)
+		}
+	})
+
+	t.Run("suggest threshold", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		threshold, err := sd.SuggestThreshold(samples)
+		require.NoError(t, err)
+		require.Greater(t, threshold, float32(0))
+		require.Less(t, threshold, float32(1))
+		require.Equal(t, cfg.Threshold, sd.Config().Threshold)
+
+		_, err = sd.SuggestThreshold(samples[:100])
+		require.Error(t, err)
+
+		var nilDetector *Detector
+		_, err = nilDetector.SuggestThreshold(samples)
+		require.EqualError(t, err, "invalid nil detector")
+	})
+
+	t.Run("optimized model file path", func(t *testing.T) {
+		cfg := cfg
+		cfg.OptimizedModelFilePath = filepath.Join(t.TempDir(), "silero_vad.optimized.onnx")
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+		_, err = os.Stat(cfg.OptimizedModelFilePath)
+		require.NoError(t, err)
+
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+	})
+
+	t.Run("reconfigure", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		newCfg := cfg
+		newCfg.ModelPath = ""
+		newCfg.Threshold = 0.6
+		newCfg.MinSilenceDurationMs = 200
+		newCfg.SpeechPadMs = 50
+		require.NoError(t, sd.Reconfigure(newCfg))
+
+		current := sd.Config()
+		require.Equal(t, cfg.ModelPath, current.ModelPath)
+		require.Equal(t, float32(0.6), current.Threshold)
+		require.InDelta(t, 0.45, current.NegativeThreshold, 1e-6)
+		require.Equal(t, 200, current.MinSilenceDurationMs)
+		require.Equal(t, 50, current.SpeechPadMs)
+
+		// The result matches that of a detector created with the new config.
+		newCfg.ModelPath = cfg.ModelPath
+		expectedDetector, err := NewDetector(newCfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, expectedDetector.Destroy())
+		}()
+		expected, err := expectedDetector.Detect(samples)
+		require.NoError(t, err)
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// Invalid configs are rejected, leaving the config untouched.
+		invalidCfg := newCfg
+		invalidCfg.Threshold = 0.3
+		invalidCfg.NegativeThreshold = 0.4
+		require.EqualError(t, sd.Reconfigure(invalidCfg), "invalid config: invalid NegativeThreshold: should be less than Threshold")
+
+		invalidCfg = newCfg
+		invalidCfg.SampleRate = 8000
+		require.EqualError(t, sd.Reconfigure(invalidCfg), "invalid config: invalid SampleRate: should not change from 16000")
+
+		invalidCfg = newCfg
+		invalidCfg.HighPassHz = 80
+		require.EqualError(t, sd.Reconfigure(invalidCfg), "invalid config: invalid HighPassHz: should not change from 0")
+
+		require.Equal(t, float32(0.6), sd.Config().Threshold)
+
+		require.NoError(t, sd.ResetConfig())
+		require.Equal(t, cfg.Threshold, sd.Config().Threshold)
+		require.Equal(t, cfg.MinSilenceDurationMs, sd.Config().MinSilenceDurationMs)
+
+		var nilDetector *Detector
+		require.EqualError(t, nilDetector.Reconfigure(cfg), "invalid nil detector")
+	})
+
+	t.Run("frame align", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{FrameAlignMs: 10}}
+
+		// Segments are extended to whole frames.
+		segment := sd.alignToFrames(Segment{SpeechStartAt: 1.056, SpeechEndAt: 1.632})
+		require.InDelta(t, 1.05, segment.SpeechStartAt, 1e-9)
+		require.InDelta(t, 1.64, segment.SpeechEndAt, 1e-9)
+
+		// Boundaries already on the grid are kept.
+		segment = sd.alignToFrames(Segment{SpeechStartAt: 0.32, SpeechEndAt: 0.96})
+		require.InDelta(t, 0.32, segment.SpeechStartAt, 1e-9)
+		require.InDelta(t, 0.96, segment.SpeechEndAt, 1e-9)
+
+		// Unfinished segments only have their start aligned.
+		segment = sd.alignToFrames(Segment{SpeechStartAt: 2.888, Unfinished: true})
+		require.InDelta(t, 2.88, segment.SpeechStartAt, 1e-9)
+		require.Zero(t, segment.SpeechEndAt)
+
+		sd.cfg.FrameAlignNearest = true
+		segment = sd.alignToFrames(Segment{SpeechStartAt: 1.056, SpeechEndAt: 1.632})
+		require.InDelta(t, 1.06, segment.SpeechStartAt, 1e-9)
+		require.InDelta(t, 1.63, segment.SpeechEndAt, 1e-9)
+
+		sd.cfg.FrameAlignMs = 0
+		segment = sd.alignToFrames(Segment{SpeechStartAt: 1.056, SpeechEndAt: 1.632})
+		require.Equal(t, Segment{SpeechStartAt: 1.056, SpeechEndAt: 1.632}, segment)
+
+		// Detection returns aligned segments, both in batch and streaming.
+		detector, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, detector)
+		defer func() {
+			require.NoError(t, detector.Destroy())
+		}()
+		expected, err := detector.Detect(samples)
+		require.NoError(t, err)
+
+		cfg := cfg
+		cfg.FrameAlignMs = 10
+		aligned, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, aligned)
+		defer func() {
+			require.NoError(t, aligned.Destroy())
+		}()
+		segments, err := aligned.Detect(samples)
+		require.NoError(t, err)
+		require.Len(t, segments, len(expected))
+		for i := range segments {
+			require.Equal(t, aligned.alignToFrames(expected[i]), segments[i])
+		}
+
+		require.NoError(t, aligned.Reset())
+		var streamed []Segment
+		aligned.DetectIter(samples)(func(segment Segment, err error) bool {
+			require.NoError(t, err)
+			streamed = append(streamed, segment)
+			return true
+		})
+		require.Equal(t, segments, streamed)
+	})
+
+	t.Run("segment filter", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+
+		// Drop the first finished segment and shift the others.
+		var calls int
+		cfg := cfg
+		cfg.StartOffsetSec = 10
+		cfg.SegmentFilter = func(segment Segment) (Segment, bool) {
+			require.False(t, segment.Unfinished)
+			// The offset is applied beforehand.
+			require.GreaterOrEqual(t, segment.SpeechStartAt, 10.0)
+			calls++
+			if calls == 1 {
+				return Segment{}, false
+			}
+			segment.SpeechStartAt += 1
+			return segment, true
+		}
+		filtered, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, filtered)
+		defer func() {
+			require.NoError(t, filtered.Destroy())
+		}()
+
+		var want []Segment
+		for i, segment := range expected {
+			segment = filtered.withOffset(segment)
+			if !segment.Unfinished {
+				if i == 0 {
+					continue
+				}
+				segment.SpeechStartAt += 1
+			}
+			want = append(want, segment)
+		}
+
+		segments, err := filtered.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, want, segments)
+
+		// Streaming applies the filter as well.
+		require.NoError(t, filtered.Reset())
+		calls = 0
+		var streamed []Segment
+		filtered.DetectIter(samples)(func(segment Segment, err error) bool {
+			require.NoError(t, err)
+			streamed = append(streamed, segment)
+			return true
+		})
+		require.Equal(t, want, streamed)
+	})
+
+	t.Run("window count", func(t *testing.T) {
+		for _, padShortInput := range []bool{false, true} {
+			cfg := cfg
+			cfg.PadShortInput = padShortInput
+			sd, err := NewDetector(cfg)
+			require.NoError(t, err)
+			require.NotNil(t, sd)
+
+			for _, n := range []int{0, 100, 511, 512, 513, 1024, 8000, 16383, 16384, len(samples)} {
+				require.NoError(t, sd.Reset())
+				_, err := sd.Detect(samples[:n])
+				if err != nil {
+					require.Zero(t, sd.WindowCount(n), "samples=%d pad=%v", n, padShortInput)
+					continue
+				}
+				require.Equal(t, sd.Stats().Windows, sd.WindowCount(n), "samples=%d pad=%v", n, padShortInput)
+			}
+
+			require.NoError(t, sd.Destroy())
+		}
+	})
+
+	t.Run("window size", func(t *testing.T) {
+		require.Equal(t, 512, sd.WindowSize())
+		require.Equal(t, sd.WindowSize(), sd.HopSize())
+
+		cfg := cfg
+		cfg.SampleRate = 8000
+		sd8k, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd8k.Destroy())
+		}()
+		require.Equal(t, 256, sd8k.WindowSize())
+		require.Equal(t, 256, sd8k.HopSize())
+
+		// Each window-sized chunk fed to a stream processes a single window.
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+		require.Equal(t, 256, stream.WindowSize())
+		_, err = stream.Process(make([]float32, stream.WindowSize()))
+		require.NoError(t, err)
+		require.Equal(t, 1, stream.sd.Stats().Windows)
+	})
+
+	t.Run("tight boundaries", func(t *testing.T) {
+		cfg := cfg
+		cfg.SpeechPadMs = 30
+		cfg.TightBoundaries = true
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, trace, err := sd.DetectWithTrace(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+
+		// Segments start with a voiced window and end with one.
+		voicedEnds := map[int]bool{}
+		for _, decision := range trace {
+			if decision.Triggered && decision.Probability >= cfg.Threshold {
+				voicedEnds[decision.Sample] = true
+			}
+		}
+		for _, segment := range segments {
+			startSample := int(math.Round(segment.SpeechStartAt * 16000))
+			require.True(t, voicedEnds[startSample+512], "start %v", segment.SpeechStartAt)
+			if !segment.Unfinished {
+				endSample := int(math.Round(segment.SpeechEndAt * 16000))
+				require.True(t, voicedEnds[endSample], "end %v", segment.SpeechEndAt)
+			}
+		}
+	})
+
+	t.Run("device allocator", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		cfg := cfg
+		cfg.AllocatorType = AllocatorTypeDevice
+		sdDevice, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sdDevice)
+		defer func() {
+			require.NoError(t, sdDevice.Destroy())
+		}()
+
+		segments, err := sdDevice.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("arena limits", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		cfg := cfg
+		cfg.ArenaMaxMemory = 64 << 20
+		cfg.ArenaInitialChunkSize = 1 << 20
+		sdArena, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sdArena)
+		defer func() {
+			require.NoError(t, sdArena.Destroy())
+		}()
+
+		segments, err := sdArena.Detect(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("detect with summary", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		segments, summary, err := sd.DetectWithSummary(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+		require.Equal(t, len(segments), summary.SegmentCount)
+		require.Equal(t, float64(len(samples))/16000, summary.AudioDuration)
+		require.InDelta(t, summary.AudioDuration, summary.SpeechDuration+summary.SilenceDuration, 1e-9)
+		require.InDelta(t, summary.SpeechDuration/summary.AudioDuration, summary.SpeechRatio, 1e-9)
+
+		var nilDetector *Detector
+		_, _, err = nilDetector.DetectWithSummary(samples)
+		require.EqualError(t, err, "invalid nil detector")
+	})
+
+	t.Run("detect with summary overlapping segments", func(t *testing.T) {
+		// Padding segments by more than half the minimum silence makes them overlap.
+		cfg := cfg
+		cfg.SpeechPadMs = 1000
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, summary, err := sd.DetectWithSummary(samples)
+		require.NoError(t, err)
+		require.Greater(t, len(segments), 1)
+		require.Greater(t, segments[0].SpeechEndAt, segments[1].SpeechStartAt)
+
+		// The overlap is counted once, as with the extracted speech, up to the rounding
+		// of the bounds.
+		require.LessOrEqual(t, summary.SpeechRatio, 1.0)
+		require.GreaterOrEqual(t, summary.SilenceDuration, 0.0)
+		extracted := sd.ExtractSpeechOnly(samples, segments)
+		require.InDelta(t, float64(len(extracted))/16000, summary.SpeechDuration, float64(2*len(segments))/16000)
+	})
+
+	t.Run("detect range", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		duration := float64(len(samples)) / 16000
+
+		// The whole range matches Detect run from scratch.
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		_, err = sd.Detect(samples)
+		require.NoError(t, err)
+		segments, err := sd.DetectRange(samples, 0, duration)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// A later range matches Detect over the same samples, offset by the range start.
+		offsetCfg := cfg
+		offsetCfg.StartOffsetSec = 1
+		sdOffset, err := NewDetector(offsetCfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdOffset.Destroy())
+		}()
+
+		expected, err = sdOffset.Detect(samples[16000:64000])
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+		segments, err = sd.DetectRange(samples, 1, 4)
+		require.NoError(t, err)
+		require.Len(t, segments, len(expected))
+		for i := range segments {
+			require.InDelta(t, expected[i].SpeechStartAt, segments[i].SpeechStartAt, 1e-9)
+			require.InDelta(t, expected[i].SpeechEndAt, segments[i].SpeechEndAt, 1e-9)
+			require.Equal(t, expected[i].Unfinished, segments[i].Unfinished)
+		}
+
+		_, err = sd.DetectRange(samples, -1, 1)
+		require.EqualError(t, err, "invalid startSec: should be a positive number")
+		_, err = sd.DetectRange(samples, 2, 2)
+		require.EqualError(t, err, "invalid endSec: should be greater than startSec")
+		_, err = sd.DetectRange(samples, 0, duration+1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid endSec: should not exceed the input duration")
+	})
+
+	t.Run("ignore margins", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		detected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, detected)
+
+		// Ignore the start of the first segment and the end of the last one.
+		first := detected[0].SpeechStartAt + 0.1
+		duration := float64(len(samples)) / 16000
+		last := detected[len(detected)-1].SpeechStartAt + 0.1
+
+		var expected []Segment
+		for _, segment := range detected {
+			segment.SpeechStartAt = max(segment.SpeechStartAt, first)
+			if segment.SpeechStartAt >= last {
+				continue
+			}
+			if !segment.Unfinished {
+				segment.SpeechEndAt = min(segment.SpeechEndAt, last)
+				if segment.SpeechEndAt <= segment.SpeechStartAt {
+					continue
+				}
+			}
+			expected = append(expected, segment)
+		}
+
+		cfg := cfg
+		cfg.IgnoreFirstMs = int(math.Round(first * 1000))
+		cfg.IgnoreLastMs = int(math.Round((duration - last) * 1000))
+		sdMargins, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdMargins.Destroy())
+		}()
+
+		segments, err := sdMargins.Detect(samples)
+		require.NoError(t, err)
+		require.Len(t, segments, len(expected))
+		for i := range segments {
+			require.InDelta(t, expected[i].SpeechStartAt, segments[i].SpeechStartAt, 1e-3)
+			require.InDelta(t, expected[i].SpeechEndAt, segments[i].SpeechEndAt, 1e-3)
+			require.Equal(t, expected[i].Unfinished, segments[i].Unfinished)
+		}
+	})
+
+	t.Run("reproducibility", func(t *testing.T) {
+		run := func(sd *Detector) ([]Segment, []WindowDecision) {
+			segments, trace, err := sd.DetectWithTrace(samples)
+			require.NoError(t, err)
+			return segments, trace
+		}
+
+		first, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, first.Destroy())
+		}()
+		second, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, second.Destroy())
+		}()
+
+		// Separate detectors, as well as a reset one, produce the exact same output.
+		segments, trace := run(first)
+		require.NotEmpty(t, segments)
+		otherSegments, otherTrace := run(second)
+		require.Equal(t, segments, otherSegments)
+		require.Equal(t, trace, otherTrace)
+
+		require.NoError(t, first.Reset())
+		otherSegments, otherTrace = run(first)
+		require.Equal(t, segments, otherSegments)
+		require.Equal(t, trace, otherTrace)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		// Each window is preceded by the end of the previous one, zeros at the start.
+		first, second := samples[:512], samples[512:1024]
+		input := sd.modelInput(first)
+		require.Equal(t, make([]float32, 64), input[:64])
+		require.Equal(t, first, input[64:])
+		input = sd.modelInput(second)
+		require.Equal(t, first[512-64:], input[:64])
+		require.Equal(t, second, input[64:])
+
+		// The context is shorter at 8kHz, along with the windows.
+		cfg8k := cfg
+		cfg8k.SampleRate = 8000
+		sd8k, err := NewDetector(cfg8k)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd8k.Destroy())
+		}()
+
+		first, second = samples[:256], samples[256:512]
+		input = sd8k.modelInput(first)
+		require.Len(t, input, 32+256)
+		require.Equal(t, make([]float32, 32), input[:32])
+		require.Equal(t, first, input[32:])
+		input = sd8k.modelInput(second)
+		require.Equal(t, first[256-32:], input[:32])
+		require.Equal(t, second, input[32:])
+
+		// Carrying the context and state over makes detection differ from processing
+		// windows independently.
+		require.NoError(t, sd.Reset())
+		_, trace, err := sd.DetectWithTrace(samples)
+		require.NoError(t, err)
+
+		var differs bool
+		for i, decision := range trace {
+			require.NoError(t, sd.Reset())
+			_, independent, err := sd.DetectWithTrace(samples[i*512 : (i+2)*512])
+			require.NoError(t, err)
+			require.Len(t, independent, 1)
+			if independent[0].Probability != decision.Probability {
+				differs = true
+				break
+			}
+		}
+		require.True(t, differs)
+	})
+
+	t.Run("single utterance", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		detected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.Greater(t, len(detected), 1)
+
+		cfg := cfg
+		cfg.SingleUtterance = true
+		sdSingle, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdSingle.Destroy())
+		}()
+
+		segments, err := sdSingle.Detect(samples)
+		require.NoError(t, err)
+
+		var voiced, windows int
+		var peak float32
+		for _, segment := range detected {
+			voiced += segment.VoicedWindows
+			windows += segment.Windows
+			peak = max(peak, segment.PeakProbability)
+		}
+		last := detected[len(detected)-1]
+		require.Equal(t, []Segment{{
+			SpeechStartAt: detected[0].SpeechStartAt,
+			SpeechEndAt:   last.SpeechEndAt,
+			Unfinished:    last.Unfinished,
+		}}, segmentTimings(segments))
+		require.Equal(t, voiced, segments[0].VoicedWindows)
+		require.Equal(t, windows, segments[0].Windows)
+		require.Equal(t, peak, segments[0].PeakProbability)
+	})
+
+	t.Run("quality", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		segments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, segments)
+
+		for _, segment := range segments {
+			require.GreaterOrEqual(t, segment.Windows, segment.VoicedWindows)
+			require.Greater(t, segment.MeanProbability, float32(0))
+			require.GreaterOrEqual(t, segment.PeakProbability, segment.MeanProbability)
+			require.LessOrEqual(t, segment.PeakProbability, float32(1))
+			require.Equal(t, DefaultQualityThresholds.grade(segment), segment.Quality)
+		}
+
+		// Custom thresholds only grading as high segments that are fully voiced.
+		cfg := cfg
+		cfg.QualityThresholds = QualityThresholds{HighVoicedRatio: 1}
+		sdStrict, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdStrict.Destroy())
+		}()
+
+		segments, err = sdStrict.Detect(samples)
+		require.NoError(t, err)
+		for _, segment := range segments {
+			if segment.VoicedWindows == segment.Windows {
+				require.Equal(t, QualityHigh, segment.Quality)
+			} else {
+				require.Equal(t, QualityMedium, segment.Quality)
+			}
+		}
+	})
+
+	t.Run("g711", func(t *testing.T) {
+		_, err := sd.DetectMuLaw([]byte{0xFF})
+		require.EqualError(t, err, "invalid SampleRate: should be 8000 for G.711 audio")
+
+		cfg := cfg
+		cfg.SampleRate = 8000
+		sd8k, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd8k.Destroy())
+		}()
+
+		// Encode the samples by picking the code decoding to the closest value.
+		encode := func(pcm []float32, decode func([]byte) []float32) []byte {
+			codes := make([]byte, 256)
+			for i := range codes {
+				codes[i] = byte(i)
+			}
+			table := decode(codes)
+			data := make([]byte, len(pcm))
+			for i, sample := range pcm {
+				for code, value := range table {
+					if math.Abs(float64(value-sample)) < math.Abs(float64(table[data[i]]-sample)) {
+						data[i] = byte(code)
+					}
+				}
+			}
+			return data
+		}
+
+		pcm := audioutil.Resample(samples, 16000, 8000)
+		for _, tc := range []struct {
+			name   string
+			decode func([]byte) []float32
+			detect func([]byte) ([]Segment, error)
+		}{
+			{name: "mu-law", decode: audioutil.DecodeMuLaw, detect: sd8k.DetectMuLaw},
+			{name: "a-law", decode: audioutil.DecodeALaw, detect: sd8k.DetectALaw},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				data := encode(pcm, tc.decode)
+
+				require.NoError(t, sd8k.Reset())
+				expected, err := sd8k.Detect(tc.decode(data))
+				require.NoError(t, err)
+				require.NotEmpty(t, expected)
+
+				require.NoError(t, sd8k.Reset())
+				segments, err := tc.detect(data)
+				require.NoError(t, err)
+				require.Equal(t, expected, segments)
+			})
+		}
+	})
+
+	t.Run("time to first speech", func(t *testing.T) {
+		// timeToFirstSpeech returns the segments detected with cfg and the onset of the
+		// first one.
+		timeToFirstSpeech := func(cfg DetectorConfig) ([]Segment, float64) {
+			sd, err := NewDetector(cfg)
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, sd.Destroy())
+			}()
+
+			segments, summary, err := sd.DetectWithSummary(samples)
+			require.NoError(t, err)
+			require.NotEmpty(t, segments)
+			return segments, summary.TimeToFirstSpeech
+		}
+
+		// With tight boundaries, segments start at their onset, whatever the padding.
+		tightCfg := cfg
+		tightCfg.SpeechPadMs = 100
+		tightCfg.TightBoundaries = true
+		segments, onset := timeToFirstSpeech(tightCfg)
+		require.InDelta(t, segments[0].SpeechStartAt, onset, 1e-9)
+
+		// The onset is the same when the start of segments is padded or backed off.
+		paddedCfg := cfg
+		paddedCfg.SpeechPadMs = 100
+		paddedCfg.OnsetBackoffWindows = 2
+		segments, paddedOnset := timeToFirstSpeech(paddedCfg)
+		require.Equal(t, onset, paddedOnset)
+		require.Less(t, segments[0].SpeechStartAt, paddedOnset)
+	})
+}
+
+func BenchmarkResetDetect(b *testing.B) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(b, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(b, err)
+	defer func() {
+		require.NoError(b, sd.Destroy())
+	}()
+
+	// Detect over one second chunks, resetting in between as done when streaming
+	// independent utterances.
+	chunk := samples[:16000]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, sd.Reset())
+		_, err := sd.Detect(chunk)
+		require.NoError(b, err)
+	}
 }
@@ -0,0 +1,63 @@
+package speech
+
+// #include <stdlib.h>
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// sharedEnv is the ONNX Runtime environment used by all the detectors created with
+// UseSharedEnv. It's created along with the first of them and released when the last
+// one is destroyed.
+var sharedEnv struct {
+	mu   sync.Mutex
+	env  *C.OrtEnv
+	name *C.char
+	refs int
+}
+
+// acquireSharedEnv returns the shared environment, creating it with logLevel if it
+// doesn't exist yet. Each call must be paired with a call to releaseSharedEnv.
+func acquireSharedEnv(api *C.OrtApi, logLevel LogLevel) (*C.OrtEnv, error) {
+	sharedEnv.mu.Lock()
+	defer sharedEnv.mu.Unlock()
+
+	if sharedEnv.env == nil {
+		name := C.CString("vad")
+		var env *C.OrtEnv
+		status := C.OrtApiCreateEnv(api, logLevel.OrtLoggingLevel(), name, &env)
+		defer C.OrtApiReleaseStatus(api, status)
+		if status != nil {
+			C.free(unsafe.Pointer(name))
+			return nil, fmt.Errorf("failed to create env: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+		}
+		sharedEnv.env = env
+		sharedEnv.name = name
+	}
+
+	sharedEnv.refs++
+
+	return sharedEnv.env, nil
+}
+
+// releaseSharedEnv drops a reference to the shared environment, releasing it once
+// no detector uses it anymore.
+func releaseSharedEnv(api *C.OrtApi) {
+	sharedEnv.mu.Lock()
+	defer sharedEnv.mu.Unlock()
+
+	sharedEnv.refs--
+	if sharedEnv.refs > 0 {
+		return
+	}
+
+	C.OrtApiReleaseEnv(api, sharedEnv.env)
+	C.free(unsafe.Pointer(sharedEnv.name))
+	sharedEnv.env = nil
+	sharedEnv.name = nil
+	sharedEnv.refs = 0
+}
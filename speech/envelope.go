@@ -0,0 +1,30 @@
+package speech
+
+import "math"
+
+// computeEnvelopes sets the Envelope of segments, detected over pcm starting at the
+// given sample position. Each value of an envelope is the RMS of EnvelopeResolutionMs
+// worth of the segment samples, the last one covering the remaining samples if fewer.
+// Unfinished segments extend to the end of pcm.
+func (sd *Detector) computeEnvelopes(segments []Segment, pcm []float32, start int) {
+	// sampleAt returns the index within pcm of the sample at the given timestamp.
+	sampleAt := func(at float64) int {
+		return min(max(int(at*float64(sd.cfg.SampleRate))-start, 0), len(pcm))
+	}
+
+	step := max(sd.cfg.EnvelopeResolutionMs*sd.cfg.SampleRate/1000, 1)
+
+	for i, segment := range segments {
+		from, to := sampleAt(segment.SpeechStartAt), len(pcm)
+		if !segment.Unfinished {
+			to = max(sampleAt(segment.SpeechEndAt), from)
+		}
+
+		envelope := make([]float32, 0, (to-from+step-1)/step)
+		for j := from; j < to; j += step {
+			samples := pcm[j:min(j+step, to)]
+			envelope = append(envelope, float32(math.Sqrt(energy(samples)/float64(len(samples)))))
+		}
+		segments[i].Envelope = envelope
+	}
+}
@@ -0,0 +1,55 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeEnvelopes(t *testing.T) {
+	sd := &Detector{cfg: DetectorConfig{SampleRate: 1000, EnvelopeResolutionMs: 10}}
+
+	// constant returns n samples set to v.
+	constant := func(n int, v float32) []float32 {
+		samples := make([]float32, n)
+		for i := range samples {
+			samples[i] = v
+		}
+		return samples
+	}
+
+	t.Run("segments", func(t *testing.T) {
+		var pcm []float32
+		pcm = append(pcm, constant(100, 0)...)
+		pcm = append(pcm, constant(10, 0.5)...)
+		pcm = append(pcm, constant(15, -0.25)...)
+		pcm = append(pcm, constant(75, 0)...)
+		pcm = append(pcm, constant(20, 0.1)...)
+
+		segments := []Segment{
+			{SpeechStartAt: 0.1, SpeechEndAt: 0.125},
+			{SpeechStartAt: 0.2, Unfinished: true},
+		}
+		sd.computeEnvelopes(segments, pcm, 0)
+
+		// The last value covers the remaining 5 samples of the first segment.
+		require.InDeltaSlice(t, []float32{0.5, 0.25, 0.25}, segments[0].Envelope, 1e-6)
+		require.InDeltaSlice(t, []float32{0.1, 0.1}, segments[1].Envelope, 1e-6)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		pcm := append(constant(10, 0), constant(20, 0.5)...)
+
+		// The input starts after a second of previously processed audio.
+		segments := []Segment{{SpeechStartAt: 1.01, SpeechEndAt: 1.03}}
+		sd.computeEnvelopes(segments, pcm, 1000)
+		require.InDeltaSlice(t, []float32{0.5, 0.5}, segments[0].Envelope, 1e-6)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		segments := []Segment{{SpeechStartAt: 0.5, SpeechEndAt: 0.6}}
+		sd.computeEnvelopes(segments, constant(100, 0.5), 0)
+		require.NotNil(t, segments[0].Envelope)
+		require.Empty(t, segments[0].Envelope)
+	})
+}
@@ -0,0 +1,53 @@
+package speech
+
+import "math"
+
+// ExtractSpeechOnly returns the regions of pcm covered by segments, as detected over
+// it by Detect, concatenated into a single buffer, e.g. to shorten a recording to its
+// speech before transcription or storage. Segments are expected in order, with their
+// timestamps including the StartOffsetSec and their bounds the SpeechPadMs padding as
+// returned by Detect; those overlapping or touching each other once padded are merged,
+// so that no sample is repeated. Unfinished segments are extended to the end of pcm
+// when CloseOpenSegments is set, and skipped otherwise.
+func (sd *Detector) ExtractSpeechOnly(pcm []float32, segments []Segment) []float32 {
+	if sd == nil {
+		return nil
+	}
+
+	// sampleAt returns the index within pcm of the sample at the given timestamp, which
+	// includes the StartOffsetSec.
+	sampleAt := func(at float64) int {
+		return min(max(int(math.Round((at-sd.cfg.StartOffsetSec)*float64(sd.cfg.SampleRate))), 0), len(pcm))
+	}
+
+	var speech []float32
+	// The bounds of the merged region being accumulated, none while from is negative.
+	from, to := -1, -1
+	for _, segment := range segments {
+		if segment.Unfinished && !sd.cfg.CloseOpenSegments {
+			continue
+		}
+
+		start, end := sampleAt(segment.SpeechStartAt), len(pcm)
+		if !segment.Unfinished {
+			end = sampleAt(segment.SpeechEndAt)
+		}
+		if end <= start {
+			continue
+		}
+
+		if from >= 0 && start <= to {
+			to = max(to, end)
+			continue
+		}
+		if from >= 0 {
+			speech = append(speech, pcm[from:to]...)
+		}
+		from, to = start, end
+	}
+	if from >= 0 {
+		speech = append(speech, pcm[from:to]...)
+	}
+
+	return speech
+}
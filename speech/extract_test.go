@@ -0,0 +1,53 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSpeechOnly(t *testing.T) {
+	pcm := make([]float32, 100)
+	for i := range pcm {
+		pcm[i] = float32(i)
+	}
+
+	segments := []Segment{
+		{SpeechStartAt: 0.01, SpeechEndAt: 0.02},
+		// Overlapping the previous segment once padded.
+		{SpeechStartAt: 0.015, SpeechEndAt: 0.03},
+		{SpeechStartAt: 0.05, SpeechEndAt: 0.06},
+		{SpeechStartAt: 0.09, Unfinished: true},
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 1000}}
+		speech := sd.ExtractSpeechOnly(pcm, segments)
+		require.Equal(t, append(append([]float32{}, pcm[10:30]...), pcm[50:60]...), speech)
+	})
+
+	t.Run("close open segments", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 1000, CloseOpenSegments: true}}
+		speech := sd.ExtractSpeechOnly(pcm, segments)
+		require.Len(t, speech, 40)
+		require.Equal(t, pcm[90:], speech[30:])
+	})
+
+	t.Run("start offset", func(t *testing.T) {
+		// The segments are timestamped from the offset rather than from the start of pcm.
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 1000, StartOffsetSec: 10}}
+		offset := make([]Segment, len(segments))
+		for i, segment := range segments {
+			offset[i] = sd.withOffset(segment)
+		}
+		speech := sd.ExtractSpeechOnly(pcm, offset)
+		require.Equal(t, append(append([]float32{}, pcm[10:30]...), pcm[50:60]...), speech)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 1000}}
+		speech := sd.ExtractSpeechOnly(pcm, []Segment{{SpeechStartAt: 0.095, SpeechEndAt: 0.2}})
+		require.Equal(t, pcm[95:], speech)
+		require.Empty(t, sd.ExtractSpeechOnly(pcm, nil))
+	})
+}
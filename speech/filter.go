@@ -0,0 +1,48 @@
+package speech
+
+import "math"
+
+// biquad is a second order IIR filter, implemented in the transposed direct form II.
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+
+	// The filter state, carried over between samples.
+	z1, z2 float64
+}
+
+// newHighPass returns a Butterworth high-pass biquad with the given cutoff frequency,
+// using the coefficients of the Audio EQ Cookbook by Robert Bristow-Johnson.
+func newHighPass(cutoffHz, sampleRate float64) *biquad {
+	w0 := 2 * math.Pi * cutoffHz / sampleRate
+	cos := math.Cos(w0)
+	// A quality factor of 1/sqrt(2) gives the maximally flat Butterworth response.
+	q := 1 / math.Sqrt2
+	alpha := math.Sin(w0) / (2 * q)
+	a0 := 1 + alpha
+
+	return &biquad{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// process filters samples in place.
+func (f *biquad) process(samples []float32) {
+	for i, sample := range samples {
+		x := float64(sample)
+		y := f.b0*x + f.z1
+		f.z1 = f.b1*x - f.a1*y + f.z2
+		f.z2 = f.b2*x - f.a2*y
+		samples[i] = float32(y)
+	}
+}
+
+// reset clears the filter state.
+func (f *biquad) reset() {
+	f.z1 = 0
+	f.z2 = 0
+}
@@ -0,0 +1,60 @@
+package speech
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighPass(t *testing.T) {
+	// gain returns the gain in dB of the filter for a sine of the given frequency,
+	// measured once the filter has settled.
+	gain := func(f *biquad, freq float64) float64 {
+		f.reset()
+		samples := make([]float32, 16000)
+		for i := range samples {
+			samples[i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / 16000))
+		}
+		f.process(samples)
+
+		var in, out float64
+		for i, sample := range samples[8000:] {
+			x := math.Sin(2 * math.Pi * freq * float64(i+8000) / 16000)
+			in += x * x
+			out += float64(sample) * float64(sample)
+		}
+		return 10 * math.Log10(out/in)
+	}
+
+	f := newHighPass(80, 16000)
+
+	// Butterworth response: -3dB at the cutoff, 12dB per octave below it.
+	require.InDelta(t, -3.01, gain(f, 80), 0.05)
+	require.InDelta(t, -24.1, gain(f, 20), 0.5)
+	require.InDelta(t, 0, gain(f, 1000), 0.05)
+	require.InDelta(t, 0, gain(f, 4000), 0.05)
+
+	// DC is removed.
+	samples := make([]float32, 16000)
+	for i := range samples {
+		samples[i] = 0.5
+	}
+	f.reset()
+	f.process(samples)
+	require.InDelta(t, 0, samples[len(samples)-1], 1e-6)
+
+	// Filtering in chunks matches filtering at once.
+	samples = make([]float32, 1000)
+	for i := range samples {
+		samples[i] = float32(math.Sin(float64(i) / 7))
+	}
+	whole := append([]float32(nil), samples...)
+	f.reset()
+	f.process(whole)
+
+	f.reset()
+	f.process(samples[:333])
+	f.process(samples[333:])
+	require.Equal(t, whole, samples)
+}
@@ -0,0 +1,98 @@
+package speech
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// SampleFormat describes how raw audio samples are encoded.
+type SampleFormat int
+
+const (
+	// Signed 16-bit integer samples, little-endian.
+	SampleFormatInt16LE SampleFormat = iota + 1
+	// Signed 16-bit integer samples, big-endian.
+	SampleFormatInt16BE
+	// 32-bit IEEE 754 floating point samples, little-endian.
+	SampleFormatFloat32LE
+	// 32-bit IEEE 754 floating point samples, big-endian.
+	SampleFormatFloat32BE
+)
+
+// Width returns the size in bytes of a single sample, or zero if the format is unknown.
+func (f SampleFormat) Width() int {
+	switch f {
+	case SampleFormatInt16LE, SampleFormatInt16BE:
+		return 2
+	case SampleFormatFloat32LE, SampleFormatFloat32BE:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// String returns the name of the format as accepted by ParseSampleFormat.
+func (f SampleFormat) String() string {
+	switch f {
+	case SampleFormatInt16LE:
+		return "int16le"
+	case SampleFormatInt16BE:
+		return "int16be"
+	case SampleFormatFloat32LE:
+		return "float32le"
+	case SampleFormatFloat32BE:
+		return "float32be"
+	default:
+		return fmt.Sprintf("SampleFormat(%d)", int(f))
+	}
+}
+
+// ParseSampleFormat returns the format matching name, one of "int16le", "int16be",
+// "float32le" or "float32be".
+func ParseSampleFormat(name string) (SampleFormat, error) {
+	for _, f := range []SampleFormat{SampleFormatInt16LE, SampleFormatInt16BE, SampleFormatFloat32LE, SampleFormatFloat32BE} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sample format %q", name)
+}
+
+func (f SampleFormat) byteOrder() binary.ByteOrder {
+	if f == SampleFormatInt16BE || f == SampleFormatFloat32BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// DecodeSamples converts raw audio data encoded as format into float32 samples.
+// Integer samples are normalized to the [-1, 1] range.
+func DecodeSamples(data []byte, format SampleFormat) ([]float32, error) {
+	width := format.Width()
+	if width == 0 {
+		return nil, fmt.Errorf("invalid sample format")
+	}
+
+	if len(data)%width != 0 {
+		return nil, fmt.Errorf("invalid data length: should be a multiple of %d", width)
+	}
+
+	return appendSamples(make([]float32, 0, len(data)/width), data, format), nil
+}
+
+// appendSamples decodes data, holding whole samples encoded as format, appending the
+// samples to dst.
+func appendSamples(dst []float32, data []byte, format SampleFormat) []float32 {
+	width := format.Width()
+	order := format.byteOrder()
+	for i := 0; i < len(data); i += width {
+		switch width {
+		case 2:
+			dst = append(dst, float32(int16(order.Uint16(data[i:i+2])))/32768)
+		case 4:
+			dst = append(dst, math.Float32frombits(order.Uint32(data[i:i+4])))
+		}
+	}
+	return dst
+}
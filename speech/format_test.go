@@ -0,0 +1,82 @@
+package speech
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSamples(t *testing.T) {
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := DecodeSamples([]byte{0, 0}, SampleFormat(0))
+		require.EqualError(t, err, "invalid sample format")
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := DecodeSamples([]byte{0, 0, 0}, SampleFormatInt16LE)
+		require.EqualError(t, err, "invalid data length: should be a multiple of 2")
+
+		_, err = DecodeSamples([]byte{0, 0, 0, 0, 0, 0}, SampleFormatFloat32LE)
+		require.EqualError(t, err, "invalid data length: should be a multiple of 4")
+	})
+
+	t.Run("int16", func(t *testing.T) {
+		samples, err := DecodeSamples([]byte{0x00, 0x40, 0x00, 0x80}, SampleFormatInt16LE)
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.5, -1}, samples)
+	})
+
+	t.Run("byte orders", func(t *testing.T) {
+		values := []float32{0, 0.5, -0.25, -1, 0.999969482421875}
+
+		for _, tc := range []struct {
+			format SampleFormat
+			order  binary.AppendByteOrder
+		}{
+			{SampleFormatInt16LE, binary.LittleEndian},
+			{SampleFormatInt16BE, binary.BigEndian},
+			{SampleFormatFloat32LE, binary.LittleEndian},
+			{SampleFormatFloat32BE, binary.BigEndian},
+		} {
+			t.Run(tc.format.String(), func(t *testing.T) {
+				data := make([]byte, 0, len(values)*tc.format.Width())
+				for _, v := range values {
+					if tc.format.Width() == 2 {
+						data = tc.order.AppendUint16(data, uint16(int16(v*32768)))
+					} else {
+						data = tc.order.AppendUint32(data, math.Float32bits(v))
+					}
+				}
+
+				samples, err := DecodeSamples(data, tc.format)
+				require.NoError(t, err)
+				require.Equal(t, values, samples)
+			})
+		}
+	})
+
+	t.Run("mismatched byte order", func(t *testing.T) {
+		data := []byte{0x00, 0x40}
+
+		le, err := DecodeSamples(data, SampleFormatInt16LE)
+		require.NoError(t, err)
+		be, err := DecodeSamples(data, SampleFormatInt16BE)
+		require.NoError(t, err)
+
+		require.Equal(t, []float32{0.5}, le)
+		require.Equal(t, []float32{0.001953125}, be)
+	})
+}
+
+func TestParseSampleFormat(t *testing.T) {
+	for _, f := range []SampleFormat{SampleFormatInt16LE, SampleFormatInt16BE, SampleFormatFloat32LE, SampleFormatFloat32BE} {
+		parsed, err := ParseSampleFormat(f.String())
+		require.NoError(t, err)
+		require.Equal(t, f, parsed)
+	}
+
+	_, err := ParseSampleFormat("int24le")
+	require.EqualError(t, err, `unknown sample format "int24le"`)
+}
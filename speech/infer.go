@@ -0,0 +1,111 @@
+package speech
+
+// #cgo CFLAGS: -Wall -Werror -std=c99
+// #cgo LDFLAGS: -lonnxruntime
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// tensorDim is the C type of the tensor dimensions passed to ONNX Runtime, int64_t
+// being long on Linux and long long on macOS.
+type tensorDim = C.int64_t
+
+func (sd *Detector) infer(pcm []float32) (float32, error) {
+	// Create tensors
+	var pcmValue *C.OrtValue
+	pcmInputDims := []tensorDim{
+		1,
+		tensorDim(len(pcm)),
+	}
+	pcmData := encodeTensor(sd.cfg.ElementType, pcm, &sd.pcmEncBuf)
+	status := C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&pcmData[0]), C.size_t(len(pcmData)), &pcmInputDims[0], C.size_t(len(pcmInputDims)), sd.cfg.ElementType.ortType(), &pcmValue)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return 0, fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseValue(sd.api, pcmValue)
+
+	// The state is passed either as a single tensor or, for split state models, as
+	// its two halves: the h and c tensors.
+	stateData := encodeTensor(sd.cfg.ElementType, sd.state[:], &sd.stateEncBuf)
+	stateParts := [][]byte{stateData}
+	stateNodeInputDims := []tensorDim{2, 1, 128}
+	if sd.splitState {
+		stateParts = [][]byte{stateData[:len(stateData)/2], stateData[len(stateData)/2:]}
+		stateNodeInputDims = []tensorDim{2, 1, 64}
+	}
+	stateValues := sd.stateValues[:len(stateParts)]
+	for i, part := range stateParts {
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&part[0]), C.size_t(len(part)), &stateNodeInputDims[0], C.size_t(len(stateNodeInputDims)), sd.cfg.ElementType.ortType(), &stateValues[i])
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return 0, fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+		defer C.OrtApiReleaseValue(sd.api, stateValues[i])
+	}
+
+	var rateValue *C.OrtValue
+	rateInputDims := []tensorDim{1}
+	if sd.floatRate {
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&sd.rateFloat[0]), C.size_t(4), &rateInputDims[0], C.size_t(len(rateInputDims)), C.ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT, &rateValue)
+	} else {
+		status = C.OrtApiCreateTensorWithDataAsOrtValue(sd.api, sd.memoryInfo, unsafe.Pointer(&sd.rate[0]), C.size_t(8), &rateInputDims[0], C.size_t(len(rateInputDims)), C.ONNX_TENSOR_ELEMENT_DATA_TYPE_INT64, &rateValue)
+	}
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return 0, fmt.Errorf("failed to create value: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseValue(sd.api, rateValue)
+
+	// Run inference
+	if sd.splitState {
+		sd.inputValues = append(sd.inputValues[:0], pcmValue, rateValue, stateValues[0], stateValues[1])
+	} else {
+		sd.inputValues = append(sd.inputValues[:0], pcmValue, stateValues[0], rateValue)
+	}
+	inputs := sd.inputValues
+	// ONNX Runtime allocates the outputs left nil.
+	outputs := sd.outputValues
+	clear(outputs)
+	status = C.OrtApiRun(sd.api, sd.session, nil, &sd.inputNames[0], &inputs[0], C.size_t(len(sd.inputNames)), &sd.outputNames[0], C.size_t(len(sd.outputNames)), &outputs[0])
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return 0, fmt.Errorf("failed to run: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer func() {
+		for _, output := range outputs {
+			C.OrtApiReleaseValue(sd.api, output)
+		}
+	}()
+
+	// Get output values from tensor data
+	var prob unsafe.Pointer
+	status = C.OrtApiGetTensorMutableData(sd.api, outputs[0], &prob)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return 0, fmt.Errorf("failed to get tensor data: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+
+	// The updated state follows the probability, possibly split in parts.
+	partLen := stateLen / len(stateParts)
+	for i, output := range outputs[1:] {
+		var stateN unsafe.Pointer
+		status = C.OrtApiGetTensorMutableData(sd.api, output, &stateN)
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return 0, fmt.Errorf("failed to get tensor data: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+		decodeTensor(sd.cfg.ElementType, stateN, sd.state[i*partLen:(i+1)*partLen])
+	}
+
+	// Read the probability before releasing the output it belongs to.
+	var speechProb [1]float32
+	decodeTensor(sd.cfg.ElementType, prob, speechProb[:])
+
+	// Return speech probability
+	return speechProb[0], nil
+}
@@ -0,0 +1,56 @@
+package speech
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyStats summarizes the time spent running inference on each window.
+type LatencyStats struct {
+	// The number of inference calls measured.
+	Count int
+	// The fastest inference call.
+	Min time.Duration
+	// The average duration of an inference call.
+	Mean time.Duration
+	// The median duration of an inference call.
+	P50 time.Duration
+	// The 95th percentile duration of an inference call.
+	P95 time.Duration
+	// The slowest inference call.
+	Max time.Duration
+}
+
+func newLatencyStats(durations []time.Duration) LatencyStats {
+	if len(durations) == 0 {
+		return LatencyStats{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 0.5),
+		P95:   percentile(sorted, 0.95),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p, in the (0, 1] range, of the
+// sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(idx, 0)]
+}
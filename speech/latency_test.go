@@ -0,0 +1,44 @@
+package speech
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLatencyStats(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, LatencyStats{}, newLatencyStats(nil))
+	})
+
+	t.Run("single", func(t *testing.T) {
+		require.Equal(t, LatencyStats{
+			Count: 1,
+			Min:   time.Millisecond,
+			Mean:  time.Millisecond,
+			P50:   time.Millisecond,
+			P95:   time.Millisecond,
+			Max:   time.Millisecond,
+		}, newLatencyStats([]time.Duration{time.Millisecond}))
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		var durations []time.Duration
+		for i := 100; i > 0; i-- {
+			durations = append(durations, time.Duration(i)*time.Millisecond)
+		}
+
+		require.Equal(t, LatencyStats{
+			Count: 100,
+			Min:   time.Millisecond,
+			Mean:  50500 * time.Microsecond,
+			P50:   50 * time.Millisecond,
+			P95:   95 * time.Millisecond,
+			Max:   100 * time.Millisecond,
+		}, newLatencyStats(durations))
+
+		// The input is left untouched.
+		require.Equal(t, 100*time.Millisecond, durations[0])
+	})
+}
@@ -0,0 +1,60 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"runtime/cgo"
+)
+
+// goOrtLog is the ONNX Runtime logging function of the environments created with a
+// LogFilter, param being the handle to the filter. Messages passing the filter are
+// routed to slog.
+//
+//export goOrtLog
+func goOrtLog(param C.uintptr_t, severity C.OrtLoggingLevel, category, message *C.char) {
+	filter, ok := cgo.Handle(param).Value().(func(severity, message string) bool)
+	if !ok {
+		return
+	}
+
+	msg := C.GoString(message)
+	if !filter(ortSeverityName(severity), msg) {
+		return
+	}
+
+	slog.Log(context.Background(), ortSeverityLevel(severity), msg, slog.String("category", C.GoString(category)))
+}
+
+// ortSeverityName returns the name of severity passed to LogFilter.
+func ortSeverityName(severity C.OrtLoggingLevel) string {
+	switch severity {
+	case C.ORT_LOGGING_LEVEL_VERBOSE:
+		return "verbose"
+	case C.ORT_LOGGING_LEVEL_INFO:
+		return "info"
+	case C.ORT_LOGGING_LEVEL_WARNING:
+		return "warning"
+	case C.ORT_LOGGING_LEVEL_ERROR:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+// ortSeverityLevel returns the slog level ONNX Runtime messages of severity are logged
+// with.
+func ortSeverityLevel(severity C.OrtLoggingLevel) slog.Level {
+	switch severity {
+	case C.ORT_LOGGING_LEVEL_VERBOSE:
+		return slog.LevelDebug
+	case C.ORT_LOGGING_LEVEL_INFO:
+		return slog.LevelInfo
+	case C.ORT_LOGGING_LEVEL_WARNING:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
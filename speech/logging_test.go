@@ -0,0 +1,112 @@
+package speech
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// messageRecorder is a slog.Handler recording the messages logged, safe for use by
+// the ONNX Runtime threads.
+type messageRecorder struct {
+	mu       *sync.Mutex
+	messages *[]string
+}
+
+func (h messageRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h messageRecorder) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.messages = append(*h.messages, r.Message)
+	return nil
+}
+
+func (h messageRecorder) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h messageRecorder) WithGroup(string) slog.Handler { return h }
+
+func TestLogFilter(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	// newDetector creates a detector with filter, returning the ONNX Runtime messages
+	// the filter was called with and those logged to slog.
+	newDetector := func(t *testing.T, filter func(severity, message string) bool) ([]string, []string) {
+		var mu sync.Mutex
+		var logged []string
+		slog.SetDefault(slog.New(messageRecorder{mu: &mu, messages: &logged}))
+
+		var filtered []string
+		sd, err := NewDetector(DetectorConfig{
+			ModelPath:  "../testfiles/silero_vad.onnx",
+			SampleRate: 16000,
+			Threshold:  0.5,
+			LogLevel:   LevelVerbose,
+			LogFilter: func(severity, message string) bool {
+				require.Contains(t, []string{"verbose", "info", "warning", "error", "fatal"}, severity)
+				mu.Lock()
+				defer mu.Unlock()
+				filtered = append(filtered, message)
+				return filter(severity, message)
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, sd.Destroy())
+
+		mu.Lock()
+		defer mu.Unlock()
+		return filtered, logged
+	}
+
+	t.Run("routed", func(t *testing.T) {
+		messages, logged := newDetector(t, func(string, string) bool {
+			return true
+		})
+		require.NotEmpty(t, messages)
+		for _, message := range messages {
+			require.True(t, slices.Contains(logged, message))
+		}
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		messages, logged := newDetector(t, func(string, string) bool {
+			return false
+		})
+		require.NotEmpty(t, messages)
+		for _, message := range messages {
+			require.False(t, slices.Contains(logged, message))
+		}
+	})
+
+	t.Run("failed creation", func(t *testing.T) {
+		// The environment and the logger are released when the session can't be
+		// created.
+		_, err := NewDetector(DetectorConfig{
+			ModelPath:  "../testfiles/missing.onnx",
+			SampleRate: 16000,
+			Threshold:  0.5,
+			LogFilter: func(string, string) bool {
+				return true
+			},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create session")
+	})
+
+	t.Run("shared env", func(t *testing.T) {
+		err := DetectorConfig{
+			ModelPath:    "../testfiles/silero_vad.onnx",
+			SampleRate:   16000,
+			Threshold:    0.5,
+			UseSharedEnv: true,
+			LogFilter: func(string, string) bool {
+				return true
+			},
+		}.IsValid()
+		require.EqualError(t, err, "invalid UseSharedEnv: not supported along with LogFilter")
+	})
+}
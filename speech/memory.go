@@ -0,0 +1,104 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import "fmt"
+
+// AllocatorType is the ONNX Runtime allocator type declared by the memory info of the
+// tensors passed to the model. These tensors wrap buffers owned by the detector, so
+// that the allocator type only describes their memory to ONNX Runtime: it doesn't
+// change how memory is allocated, by the detector or by the session. ArenaMaxMemory and
+// ArenaInitialChunkSize configure the allocator the session actually uses.
+type AllocatorType int
+
+const (
+	// Declares the tensors as allocated by the arena allocator. This is the default.
+	AllocatorTypeArena AllocatorType = iota + 1
+	// Declares the tensors as allocated by the device allocator.
+	AllocatorTypeDevice
+)
+
+func (t AllocatorType) ortType() C.enum_OrtAllocatorType {
+	if t == AllocatorTypeDevice {
+		return C.OrtDeviceAllocator
+	}
+	return C.OrtArenaAllocator
+}
+
+// MemType is the ONNX Runtime memory type of the CPU memory of the tensors.
+type MemType int
+
+const (
+	// The default memory type. This is the default.
+	MemTypeDefault MemType = iota + 1
+	// CPU accessible memory allocated by a non-CPU execution provider, for its inputs.
+	MemTypeCPUInput
+	// CPU accessible memory allocated by a non-CPU execution provider, for its outputs.
+	MemTypeCPUOutput
+)
+
+func (t MemType) ortType() C.enum_OrtMemType {
+	switch t {
+	case MemTypeCPUInput:
+		return C.OrtMemTypeCPUInput
+	case MemTypeCPUOutput:
+		return C.OrtMemTypeCPUOutput
+	default:
+		return C.OrtMemTypeDefault
+	}
+}
+
+// registerArena registers a CPU arena allocator configured after ArenaMaxMemory and
+// ArenaInitialChunkSize on the environment of the detector, and has the session use
+// it, to be called before the session is created.
+func (sd *Detector) registerArena() error {
+	var (
+		keys   []*C.char
+		values []C.size_t
+	)
+	addKey := func(key string, value int) {
+		sd.cStrings[key] = C.CString(key)
+		keys = append(keys, sd.cStrings[key])
+		values = append(values, C.size_t(value))
+	}
+	if sd.cfg.ArenaMaxMemory > 0 {
+		addKey("max_mem", sd.cfg.ArenaMaxMemory)
+	}
+	if sd.cfg.ArenaInitialChunkSize > 0 {
+		addKey("initial_chunk_size_bytes", sd.cfg.ArenaInitialChunkSize)
+	}
+
+	var arenaCfg *C.OrtArenaCfg
+	status := C.OrtApiCreateArenaCfgV2(sd.api, &keys[0], &values[0], C.size_t(len(keys)), &arenaCfg)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create arena config: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseArenaCfg(sd.api, arenaCfg)
+
+	var memoryInfo *C.OrtMemoryInfo
+	status = C.OrtApiCreateCpuMemoryInfo(sd.api, C.OrtArenaAllocator, C.OrtMemTypeDefault, &memoryInfo)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create memory info: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseMemoryInfo(sd.api, memoryInfo)
+
+	status = C.OrtApiCreateAndRegisterAllocator(sd.api, sd.env, memoryInfo, arenaCfg)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to register allocator: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+
+	// Sessions only use the allocators registered on the environment when told to.
+	sd.cStrings["session.use_env_allocators"] = C.CString("session.use_env_allocators")
+	sd.cStrings["1"] = C.CString("1")
+	status = C.OrtApiAddSessionConfigEntry(sd.api, sd.sessionOpts, sd.cStrings["session.use_env_allocators"], sd.cStrings["1"])
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return fmt.Errorf("failed to set session config entry: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+
+	return nil
+}
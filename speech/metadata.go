@@ -0,0 +1,121 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+	"unsafe"
+)
+
+// ModelMetadata returns the metadata embedded in the loaded model. Along with any custom
+// entries, the map holds the standard "producer_name", "graph_name", "domain",
+// "description" and "version" fields, which take precedence over custom entries
+// with the same keys. It doesn't require running inference.
+func (sd *Detector) ModelMetadata() (map[string]string, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if err := sd.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	var allocator *C.OrtAllocator
+	status := C.OrtApiGetAllocatorWithDefaultOptions(sd.api, &allocator)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get allocator: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+
+	var metadata *C.OrtModelMetadata
+	status = C.OrtApiSessionGetModelMetadata(sd.api, sd.session, &metadata)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get model metadata: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	defer C.OrtApiReleaseModelMetadata(sd.api, metadata)
+
+	// free releases memory allocated by ORT.
+	free := func(p unsafe.Pointer) {
+		C.OrtApiReleaseStatus(sd.api, C.OrtApiAllocatorFree(sd.api, allocator, p))
+	}
+	// getString converts a string allocated by ORT, freeing it.
+	getString := func(value *C.char) string {
+		defer free(unsafe.Pointer(value))
+		return C.GoString(value)
+	}
+
+	var keys **C.char
+	var numKeys C.int64_t
+	status = C.OrtApiModelMetadataGetCustomMetadataMapKeys(sd.api, metadata, allocator, &keys, &numKeys)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get custom metadata keys: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+
+	m := map[string]string{}
+	if keys != nil {
+		defer free(unsafe.Pointer(keys))
+
+		var err error
+		for _, key := range unsafe.Slice(keys, int(numKeys)) {
+			// Keep freeing the remaining keys after a failure.
+			if err != nil {
+				getString(key)
+				continue
+			}
+
+			var value *C.char
+			status := C.OrtApiModelMetadataLookupCustomMetadataMap(sd.api, metadata, allocator, key, &value)
+			if status != nil {
+				err = fmt.Errorf("failed to lookup custom metadata: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+				C.OrtApiReleaseStatus(sd.api, status)
+				getString(key)
+				continue
+			}
+			m[getString(key)] = getString(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fields := []struct {
+		key string
+		get func(*C.OrtApi, *C.OrtModelMetadata, *C.OrtAllocator, **C.char) *C.OrtStatus
+	}{
+		{"producer_name", func(api *C.OrtApi, md *C.OrtModelMetadata, a *C.OrtAllocator, v **C.char) *C.OrtStatus {
+			return C.OrtApiModelMetadataGetProducerName(api, md, a, v)
+		}},
+		{"graph_name", func(api *C.OrtApi, md *C.OrtModelMetadata, a *C.OrtAllocator, v **C.char) *C.OrtStatus {
+			return C.OrtApiModelMetadataGetGraphName(api, md, a, v)
+		}},
+		{"domain", func(api *C.OrtApi, md *C.OrtModelMetadata, a *C.OrtAllocator, v **C.char) *C.OrtStatus {
+			return C.OrtApiModelMetadataGetDomain(api, md, a, v)
+		}},
+		{"description", func(api *C.OrtApi, md *C.OrtModelMetadata, a *C.OrtAllocator, v **C.char) *C.OrtStatus {
+			return C.OrtApiModelMetadataGetDescription(api, md, a, v)
+		}},
+	}
+	for _, field := range fields {
+		var value *C.char
+		status := field.get(sd.api, metadata, allocator, &value)
+		defer C.OrtApiReleaseStatus(sd.api, status)
+		if status != nil {
+			return nil, fmt.Errorf("failed to get model %s: %s", field.key, C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+		}
+		m[field.key] = getString(value)
+	}
+
+	var version C.int64_t
+	status = C.OrtApiModelMetadataGetVersion(sd.api, metadata, &version)
+	defer C.OrtApiReleaseStatus(sd.api, status)
+	if status != nil {
+		return nil, fmt.Errorf("failed to get model version: %s", C.GoString(C.OrtApiGetErrorMessage(sd.api, status)))
+	}
+	m["version"] = strconv.FormatInt(int64(version), 10)
+
+	return m, nil
+}
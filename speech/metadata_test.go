@@ -0,0 +1,31 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelMetadata(t *testing.T) {
+	sd, err := NewDetector(DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	metadata, err := sd.ModelMetadata()
+	require.NoError(t, err)
+	for _, key := range []string{"producer_name", "graph_name", "domain", "description", "version"} {
+		require.Contains(t, metadata, key)
+	}
+	require.NotEmpty(t, metadata["version"])
+
+	var nilDetector *Detector
+	_, err = nilDetector.ModelMetadata()
+	require.EqualError(t, err, "invalid nil detector")
+}
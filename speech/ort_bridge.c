@@ -6,6 +6,10 @@
 
 #include "ort_bridge.h"
 
+int OrtBridgeVersion() {
+  return ORT_BRIDGE_VERSION;
+}
+
 const OrtApi* OrtGetApi() {
   return OrtGetApiBase()->GetApi(ORT_API_VERSION);
 }
@@ -22,6 +26,18 @@ OrtStatus* OrtApiCreateEnv(OrtApi* api, OrtLoggingLevel log_level, const char* l
   return api->CreateEnv(log_level, log_id, env);
 }
 
+// Implemented on the Go side, see logging.go.
+extern void goOrtLog(uintptr_t param, OrtLoggingLevel severity, char* category, char* message);
+
+static void ortBridgeLog(void* param, OrtLoggingLevel severity, const char* category, const char* logid,
+    const char* code_location, const char* message) {
+  goOrtLog((uintptr_t)param, severity, (char*)category, (char*)message);
+}
+
+OrtStatus* OrtApiCreateEnvWithCustomLogger(OrtApi* api, uintptr_t logger_param, OrtLoggingLevel log_level, const char* log_id, OrtEnv** env) {
+  return api->CreateEnvWithCustomLogger(ortBridgeLog, (void*)logger_param, log_level, log_id, env);
+}
+
 void OrtApiReleaseEnv(OrtApi* api, OrtEnv* env) {
   return api->ReleaseEnv(env);
 }
@@ -46,6 +62,14 @@ OrtStatus* OrtApiSetSessionGraphOptimizationLevel(OrtApi* api, OrtSessionOptions
   return api->SetSessionGraphOptimizationLevel(opts, graph_optimization_level);
 }
 
+OrtStatus* OrtApiSetOptimizedModelFilePath(OrtApi* api, OrtSessionOptions* opts, const char* optimized_model_filepath) {
+  return api->SetOptimizedModelFilePath(opts, optimized_model_filepath);
+}
+
+OrtStatus* OrtApiAddSessionConfigEntry(OrtApi* api, OrtSessionOptions* opts, const char* config_key, const char* config_value) {
+  return api->AddSessionConfigEntry(opts, config_key, config_value);
+}
+
 OrtStatus* OrtApiCreateSession(OrtApi* api, OrtEnv* env, const char* model_path, OrtSessionOptions* opts, OrtSession** session) {
   return api->CreateSession(env, model_path, opts, session);
 }
@@ -62,6 +86,19 @@ void OrtApiReleaseMemoryInfo(OrtApi* api, OrtMemoryInfo *minfo) {
   return api->ReleaseMemoryInfo(minfo);
 }
 
+OrtStatus* OrtApiCreateArenaCfgV2(OrtApi* api, const char* const* arena_config_keys, const size_t* arena_config_values,
+    size_t num_keys, OrtArenaCfg** out) {
+  return api->CreateArenaCfgV2(arena_config_keys, arena_config_values, num_keys, out);
+}
+
+void OrtApiReleaseArenaCfg(OrtApi* api, OrtArenaCfg* arena_cfg) {
+  api->ReleaseArenaCfg(arena_cfg);
+}
+
+OrtStatus* OrtApiCreateAndRegisterAllocator(OrtApi* api, OrtEnv* env, const OrtMemoryInfo* minfo, const OrtArenaCfg* arena_cfg) {
+  return api->CreateAndRegisterAllocator(env, minfo, arena_cfg);
+}
+
 OrtStatus* OrtApiCreateTensorWithDataAsOrtValue(OrtApi* api, const OrtMemoryInfo* minfo, void* data,
     size_t data_len, const int64_t* shape, size_t shape_len, ONNXTensorElementDataType data_type, OrtValue** value) {
   return api->CreateTensorWithDataAsOrtValue(minfo, data, data_len, shape, shape_len, data_type, value);
@@ -80,3 +117,87 @@ OrtStatus* OrtApiRun(OrtApi* api, OrtSession* session, const OrtRunOptions* run_
 OrtStatus* OrtApiGetTensorMutableData(OrtApi* api, OrtValue* value, void** data) {
   return api->GetTensorMutableData(value, data);
 }
+
+OrtStatus* OrtApiGetAllocatorWithDefaultOptions(OrtApi* api, OrtAllocator** allocator) {
+  return api->GetAllocatorWithDefaultOptions(allocator);
+}
+
+OrtStatus* OrtApiAllocatorFree(OrtApi* api, OrtAllocator* allocator, void* p) {
+  return api->AllocatorFree(allocator, p);
+}
+
+OrtStatus* OrtApiSessionGetModelMetadata(OrtApi* api, OrtSession* session, OrtModelMetadata** metadata) {
+  return api->SessionGetModelMetadata(session, metadata);
+}
+
+void OrtApiReleaseModelMetadata(OrtApi* api, OrtModelMetadata* metadata) {
+  return api->ReleaseModelMetadata(metadata);
+}
+
+OrtStatus* OrtApiModelMetadataGetProducerName(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value) {
+  return api->ModelMetadataGetProducerName(metadata, allocator, value);
+}
+
+OrtStatus* OrtApiModelMetadataGetGraphName(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value) {
+  return api->ModelMetadataGetGraphName(metadata, allocator, value);
+}
+
+OrtStatus* OrtApiModelMetadataGetDomain(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value) {
+  return api->ModelMetadataGetDomain(metadata, allocator, value);
+}
+
+OrtStatus* OrtApiModelMetadataGetDescription(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value) {
+  return api->ModelMetadataGetDescription(metadata, allocator, value);
+}
+
+OrtStatus* OrtApiModelMetadataGetVersion(OrtApi* api, OrtModelMetadata* metadata, int64_t* value) {
+  return api->ModelMetadataGetVersion(metadata, value);
+}
+
+OrtStatus* OrtApiModelMetadataGetCustomMetadataMapKeys(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char*** keys, int64_t* num_keys) {
+  return api->ModelMetadataGetCustomMetadataMapKeys(metadata, allocator, keys, num_keys);
+}
+
+OrtStatus* OrtApiModelMetadataLookupCustomMetadataMap(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, const char* key, char** value) {
+  return api->ModelMetadataLookupCustomMetadataMap(metadata, allocator, key, value);
+}
+
+OrtStatus* OrtApiSessionGetInputCount(OrtApi* api, OrtSession* session, size_t* count) {
+  return api->SessionGetInputCount(session, count);
+}
+
+OrtStatus* OrtApiSessionGetInputName(OrtApi* api, OrtSession* session, size_t index, OrtAllocator* allocator, char** value) {
+  return api->SessionGetInputName(session, index, allocator, value);
+}
+
+OrtStatus* OrtApiSessionGetInputTypeInfo(OrtApi* api, OrtSession* session, size_t index, OrtTypeInfo** type_info) {
+  return api->SessionGetInputTypeInfo(session, index, type_info);
+}
+
+OrtStatus* OrtApiCastTypeInfoToTensorInfo(OrtApi* api, OrtTypeInfo* type_info, const OrtTensorTypeAndShapeInfo** out) {
+  return api->CastTypeInfoToTensorInfo(type_info, out);
+}
+
+OrtStatus* OrtApiGetTensorElementType(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, ONNXTensorElementDataType* out) {
+  return api->GetTensorElementType(info, out);
+}
+
+OrtStatus* OrtApiGetDimensionsCount(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, size_t* out) {
+  return api->GetDimensionsCount(info, out);
+}
+
+OrtStatus* OrtApiGetDimensions(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, int64_t* dim_values, size_t dim_values_length) {
+  return api->GetDimensions(info, dim_values, dim_values_length);
+}
+
+void OrtApiReleaseTypeInfo(OrtApi* api, OrtTypeInfo* type_info) {
+  api->ReleaseTypeInfo(type_info);
+}
+
+OrtStatus* OrtApiSessionGetOutputCount(OrtApi* api, OrtSession* session, size_t* count) {
+  return api->SessionGetOutputCount(session, count);
+}
+
+OrtStatus* OrtApiSessionGetOutputName(OrtApi* api, OrtSession* session, size_t index, OrtAllocator* allocator, char** value) {
+  return api->SessionGetOutputName(session, index, allocator, value);
+}
@@ -1,5 +1,13 @@
+#include <stdint.h>
+
 #include <onnxruntime_c_api.h>
 
+// The version of the bridge, to be bumped on any change to it. It must match
+// bridgeVersion on the Go side.
+#define ORT_BRIDGE_VERSION 6
+
+int OrtBridgeVersion();
+
 const OrtApi* OrtGetApi();
 
 const char* OrtApiGetErrorMessage(OrtApi *api, OrtStatus *status);
@@ -7,6 +15,7 @@ const char* OrtApiGetErrorMessage(OrtApi *api, OrtStatus *status);
 void OrtApiReleaseStatus(OrtApi *api, OrtStatus *status);
 
 OrtStatus* OrtApiCreateEnv(OrtApi *api, OrtLoggingLevel log_level, const char *log_id, OrtEnv **env);
+OrtStatus* OrtApiCreateEnvWithCustomLogger(OrtApi* api, uintptr_t logger_param, OrtLoggingLevel log_level, const char* log_id, OrtEnv** env);
 void OrtApiReleaseEnv(OrtApi *api, OrtEnv *env);
 
 OrtStatus* OrtApiCreateSessionOptions(OrtApi* api, OrtSessionOptions** opts);
@@ -15,6 +24,8 @@ void OrtApiReleaseSessionOptions(OrtApi* api, OrtSessionOptions* opts);
 OrtStatus* OrtApiSetIntraOpNumThreads(OrtApi* api, OrtSessionOptions* opts, int intra_op_num_threads);
 OrtStatus* OrtApiSetInterOpNumThreads(OrtApi* api, OrtSessionOptions* opts, int inter_op_num_threads);
 OrtStatus* OrtApiSetSessionGraphOptimizationLevel(OrtApi* api, OrtSessionOptions* opts, GraphOptimizationLevel graph_optimization_level);
+OrtStatus* OrtApiSetOptimizedModelFilePath(OrtApi* api, OrtSessionOptions* opts, const char* optimized_model_filepath);
+OrtStatus* OrtApiAddSessionConfigEntry(OrtApi* api, OrtSessionOptions* opts, const char* config_key, const char* config_value);
 
 OrtStatus* OrtApiCreateSession(OrtApi* api, OrtEnv* env, const char* model_path, OrtSessionOptions* opts, OrtSession** session);
 void OrtApiReleaseSession(OrtApi* api, OrtSession* session);
@@ -22,6 +33,11 @@ void OrtApiReleaseSession(OrtApi* api, OrtSession* session);
 OrtStatus* OrtApiCreateCpuMemoryInfo(OrtApi* api, enum OrtAllocatorType alloc_type, enum OrtMemType mem_type, OrtMemoryInfo** minfo);
 void OrtApiReleaseMemoryInfo(OrtApi* api, OrtMemoryInfo *minfo);
 
+OrtStatus* OrtApiCreateArenaCfgV2(OrtApi* api, const char* const* arena_config_keys, const size_t* arena_config_values,
+    size_t num_keys, OrtArenaCfg** out);
+void OrtApiReleaseArenaCfg(OrtApi* api, OrtArenaCfg* arena_cfg);
+OrtStatus* OrtApiCreateAndRegisterAllocator(OrtApi* api, OrtEnv* env, const OrtMemoryInfo* minfo, const OrtArenaCfg* arena_cfg);
+
 OrtStatus* OrtApiCreateTensorWithDataAsOrtValue(OrtApi* api, const OrtMemoryInfo* minfo, void* data, size_t data_len,
     const int64_t* shape, size_t shape_len, ONNXTensorElementDataType data_type, OrtValue** value);
 void OrtApiReleaseValue(OrtApi* api, OrtValue *value);
@@ -31,3 +47,27 @@ OrtStatus* OrtApiRun(OrtApi* api, OrtSession* session, const OrtRunOptions* run_
     const char* const* output_names, size_t output_names_len, OrtValue** outputs);
 
 OrtStatus* OrtApiGetTensorMutableData(OrtApi* api, OrtValue* value, void** data);
+
+OrtStatus* OrtApiGetAllocatorWithDefaultOptions(OrtApi* api, OrtAllocator** allocator);
+OrtStatus* OrtApiAllocatorFree(OrtApi* api, OrtAllocator* allocator, void* p);
+
+OrtStatus* OrtApiSessionGetModelMetadata(OrtApi* api, OrtSession* session, OrtModelMetadata** metadata);
+void OrtApiReleaseModelMetadata(OrtApi* api, OrtModelMetadata* metadata);
+OrtStatus* OrtApiModelMetadataGetProducerName(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value);
+OrtStatus* OrtApiModelMetadataGetGraphName(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value);
+OrtStatus* OrtApiModelMetadataGetDomain(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value);
+OrtStatus* OrtApiModelMetadataGetDescription(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char** value);
+OrtStatus* OrtApiModelMetadataGetVersion(OrtApi* api, OrtModelMetadata* metadata, int64_t* value);
+OrtStatus* OrtApiModelMetadataGetCustomMetadataMapKeys(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, char*** keys, int64_t* num_keys);
+OrtStatus* OrtApiModelMetadataLookupCustomMetadataMap(OrtApi* api, OrtModelMetadata* metadata, OrtAllocator* allocator, const char* key, char** value);
+
+OrtStatus* OrtApiSessionGetInputCount(OrtApi* api, OrtSession* session, size_t* count);
+OrtStatus* OrtApiSessionGetInputName(OrtApi* api, OrtSession* session, size_t index, OrtAllocator* allocator, char** value);
+OrtStatus* OrtApiSessionGetInputTypeInfo(OrtApi* api, OrtSession* session, size_t index, OrtTypeInfo** type_info);
+OrtStatus* OrtApiCastTypeInfoToTensorInfo(OrtApi* api, OrtTypeInfo* type_info, const OrtTensorTypeAndShapeInfo** out);
+OrtStatus* OrtApiGetTensorElementType(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, ONNXTensorElementDataType* out);
+OrtStatus* OrtApiGetDimensionsCount(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, size_t* out);
+OrtStatus* OrtApiGetDimensions(OrtApi* api, const OrtTensorTypeAndShapeInfo* info, int64_t* dim_values, size_t dim_values_length);
+void OrtApiReleaseTypeInfo(OrtApi* api, OrtTypeInfo* type_info);
+OrtStatus* OrtApiSessionGetOutputCount(OrtApi* api, OrtSession* session, size_t* count);
+OrtStatus* OrtApiSessionGetOutputName(OrtApi* api, OrtSession* session, size_t index, OrtAllocator* allocator, char** value);
@@ -0,0 +1,59 @@
+package speech
+
+// DefaultConfig returns a config for 16 kHz audio with commonly used settings, loading
+// the model at modelPath: a Threshold of 0.5 and a NegativeThreshold of 0.35, 500ms of
+// silence to end a segment, 250ms of speech to keep it and 30ms of padding. Fields can
+// be overridden as needed before creating a detector with it.
+func DefaultConfig(modelPath string) DetectorConfig {
+	return DetectorConfig{
+		ModelPath:            modelPath,
+		SampleRate:           16000,
+		Threshold:            0.5,
+		NegativeThreshold:    0.35,
+		MinSilenceDurationMs: 500,
+		MinSpeechDurationMs:  250,
+		SpeechPadMs:          30,
+	}
+}
+
+// PresetConversational returns a config tuned for conversations, such as meetings and
+// interviews. It builds on DefaultConfig, waiting for 700ms of silence before ending a
+// segment and padding segments by 100ms, so that natural pauses between words and
+// sentences don't split an utterance.
+func PresetConversational(modelPath string) DetectorConfig {
+	cfg := DefaultConfig(modelPath)
+	cfg.MinSilenceDurationMs = 700
+	cfg.SpeechPadMs = 100
+	return cfg
+}
+
+// PresetNoisy returns a config tuned for noisy environments, reducing false positives.
+// It builds on DefaultConfig, raising Threshold to 0.6 and NegativeThreshold to 0.45,
+// requiring two consecutive speech windows to start a segment, smoothing probabilities
+// over three windows, keeping only segments of at least 300ms and waiting for 700ms of
+// silence before ending them, padded by 50ms.
+func PresetNoisy(modelPath string) DetectorConfig {
+	cfg := DefaultConfig(modelPath)
+	cfg.Threshold = 0.6
+	cfg.NegativeThreshold = 0.45
+	cfg.TriggerWindows = 2
+	cfg.ProbSmoothingWindows = 3
+	cfg.MinSilenceDurationMs = 700
+	cfg.MinSpeechDurationMs = 300
+	cfg.SpeechPadMs = 50
+	return cfg
+}
+
+// PresetShortCommands returns a config tuned for short voice commands, responding
+// quickly. It builds on DefaultConfig, raising NegativeThreshold to 0.4, ending segments
+// after 200ms of silence, keeping segments of at least 200ms, padded by 10ms, and
+// padding inputs shorter than a second (see PadShortInput).
+func PresetShortCommands(modelPath string) DetectorConfig {
+	cfg := DefaultConfig(modelPath)
+	cfg.NegativeThreshold = 0.4
+	cfg.MinSilenceDurationMs = 200
+	cfg.MinSpeechDurationMs = 200
+	cfg.SpeechPadMs = 10
+	cfg.PadShortInput = true
+	return cfg
+}
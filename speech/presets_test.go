@@ -0,0 +1,32 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	tcs := []struct {
+		name   string
+		preset func(modelPath string) DetectorConfig
+	}{
+		{name: "default", preset: DefaultConfig},
+		{name: "conversational", preset: PresetConversational},
+		{name: "noisy", preset: PresetNoisy},
+		{name: "short commands", preset: PresetShortCommands},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.preset("../testfiles/silero_vad.onnx")
+			require.Equal(t, "../testfiles/silero_vad.onnx", cfg.ModelPath)
+			require.NoError(t, cfg.IsValid())
+
+			sd, err := NewDetector(cfg)
+			require.NoError(t, err)
+			require.NotNil(t, sd)
+			require.NoError(t, sd.Destroy())
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package speech
+
+import "fmt"
+
+// SegmentFromProbs runs the segmentation of Detect over the given speech probabilities,
+// one per window, without any model involved. It allows exercising the thresholds and
+// post-processing settings of cfg in isolation, or applying them to the output of
+// another voice activity detector. Timestamps are derived from the window size at the
+// configured SampleRate. The probabilities are compared to the thresholds as is, like
+// those returned by DetectDetailed with OutputTypeProbability, so ProbSmoothingWindows
+// doesn't apply. The model related settings of cfg aren't required and, having no
+// audio, EstimateSNR and EnvelopeResolutionMs don't apply either.
+func SegmentFromProbs(cfg DetectorConfig, probs []float32) ([]Segment, error) {
+	if err := cfg.validateParams(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	sd := &Detector{cfg: cfg.withDefaults()}
+	windowSize := sd.windowSize()
+
+	return sd.segment(func(fn func(speechProb float32) error) error {
+		for _, speechProb := range probs {
+			sd.currSample += windowSize
+			if err := fn(speechProb); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil, 0, len(probs)*windowSize, 0, nil)
+}
@@ -0,0 +1,139 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentFromProbs(t *testing.T) {
+	cfg := DetectorConfig{
+		SampleRate:           16000,
+		Threshold:            0.5,
+		MinSilenceDurationMs: 100,
+	}
+
+	t.Run("synthetic", func(t *testing.T) {
+		// 10 windows of silence, 20 of speech, 10 of silence and 5 of speech.
+		var probs []float32
+		for _, run := range []struct {
+			n    int
+			prob float32
+		}{{10, 0.1}, {20, 0.9}, {10, 0.1}, {5, 0.9}} {
+			for i := 0; i < run.n; i++ {
+				probs = append(probs, run.prob)
+			}
+		}
+
+		segments, err := SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Len(t, segments, 2)
+
+		windowSec := 512.0 / 16000
+		require.InDelta(t, 10*windowSec, segments[0].SpeechStartAt, 1e-9)
+		require.False(t, segments[0].Unfinished)
+		require.Greater(t, segments[0].SpeechEndAt, 30*windowSec-1e-9)
+		require.Less(t, segments[0].SpeechEndAt, 40*windowSec)
+		require.Equal(t, 20, segments[0].VoicedWindows)
+
+		require.InDelta(t, 40*windowSec, segments[1].SpeechStartAt, 1e-9)
+		require.True(t, segments[1].Unfinished)
+
+		// Post-processing settings apply as well.
+		cfg := cfg
+		cfg.SingleUtterance = true
+		segments, err = SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+	})
+
+	t.Run("onset backoff", func(t *testing.T) {
+		// Silence, then a rising onset below the threshold before speech.
+		probs := []float32{0.05, 0.05, 0.05, 0.05, 0.1, 0.2, 0.3}
+		for i := 0; i < 20; i++ {
+			probs = append(probs, 0.9)
+		}
+		for i := 0; i < 10; i++ {
+			probs = append(probs, 0.05)
+		}
+
+		windowSec := 512.0 / 16000
+		for _, tc := range []struct {
+			backoff int
+			start   float64
+		}{
+			{backoff: 0, start: 7 * windowSec},
+			{backoff: 2, start: 5 * windowSec},
+			// Backing off stops at the first window not lower than the next one.
+			{backoff: 10, start: 3 * windowSec},
+		} {
+			cfg := cfg
+			cfg.OnsetBackoffWindows = tc.backoff
+			segments, err := SegmentFromProbs(cfg, probs)
+			require.NoError(t, err)
+			require.Len(t, segments, 1)
+			require.InDelta(t, tc.start, segments[0].SpeechStartAt, 1e-9)
+		}
+	})
+
+	t.Run("keep short last segment", func(t *testing.T) {
+		// Long speech, then short speech twice, each followed by silence.
+		var probs []float32
+		for _, run := range []struct {
+			n    int
+			prob float32
+		}{{20, 0.9}, {10, 0.1}, {3, 0.9}, {10, 0.1}, {3, 0.9}, {10, 0.1}} {
+			for i := 0; i < run.n; i++ {
+				probs = append(probs, run.prob)
+			}
+		}
+
+		cfg := cfg
+		cfg.MinSpeechDurationMs = 250
+		segments, err := SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+
+		// Only the last of the short segments is kept.
+		cfg.KeepShortLastSegment = true
+		segments, err = SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Len(t, segments, 2)
+		require.InDelta(t, 43*512.0/16000, segments[1].SpeechStartAt, 1e-9)
+		require.False(t, segments[1].Unfinished)
+
+		// The last finished segment is kept when the input ends during speech.
+		probs = append(probs, 0.9, 0.9, 0.9)
+		segments, err = SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Len(t, segments, 3)
+		require.InDelta(t, 43*512.0/16000, segments[1].SpeechStartAt, 1e-9)
+		require.False(t, segments[1].Unfinished)
+		require.True(t, segments[2].Unfinished)
+	})
+
+	t.Run("model probabilities", func(t *testing.T) {
+		cfg := cfg
+		cfg.ModelPath = "../testfiles/silero_vad.onnx"
+		// DetectDetailed returns the smoothed probabilities, which aren't smoothed again.
+		cfg.ProbSmoothingWindows = 3
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, probs, err := sd.DetectDetailed(readSamplesFromFile(t, "../testfiles/samples.pcm"))
+		require.NoError(t, err)
+
+		segments, err := SegmentFromProbs(cfg, probs)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := SegmentFromProbs(DetectorConfig{SampleRate: 16000}, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid config: invalid Threshold")
+	})
+}
@@ -0,0 +1,108 @@
+package speech
+
+import "fmt"
+
+// Quality is a coarse grade of how confidently a segment was detected as speech,
+// for routing decisions that don't need the raw probabilities.
+type Quality int
+
+const (
+	// Speech detected with a low confidence, or only sparsely voiced.
+	QualityLow Quality = iota + 1
+	// Speech detected with a moderate confidence.
+	QualityMedium
+	// Speech detected with a high confidence, mostly voiced.
+	QualityHigh
+)
+
+// String returns the name of the grade.
+func (q Quality) String() string {
+	switch q {
+	case QualityLow:
+		return "low"
+	case QualityMedium:
+		return "medium"
+	case QualityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Quality(%d)", int(q))
+	}
+}
+
+// QualityThresholds are the thresholds segments are graded with. A segment is graded
+// QualityHigh when both its MeanProbability and its ratio of voiced windows
+// (VoicedWindows over Windows) reach the high thresholds, QualityMedium when they reach
+// the medium ones, and QualityLow otherwise.
+type QualityThresholds struct {
+	HighMeanProbability   float32
+	HighVoicedRatio       float32
+	MediumMeanProbability float32
+	MediumVoicedRatio     float32
+}
+
+// DefaultQualityThresholds are the thresholds used when DetectorConfig.QualityThresholds
+// is left unset.
+var DefaultQualityThresholds = QualityThresholds{
+	HighMeanProbability:   0.8,
+	HighVoicedRatio:       0.7,
+	MediumMeanProbability: 0.6,
+	MediumVoicedRatio:     0.4,
+}
+
+func (t QualityThresholds) validate() error {
+	for _, v := range []float32{t.HighMeanProbability, t.HighVoicedRatio, t.MediumMeanProbability, t.MediumVoicedRatio} {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("invalid QualityThresholds: should be in range [0, 1]")
+		}
+	}
+
+	if t.MediumMeanProbability > t.HighMeanProbability || t.MediumVoicedRatio > t.HighVoicedRatio {
+		return fmt.Errorf("invalid QualityThresholds: medium thresholds should not exceed high ones")
+	}
+
+	return nil
+}
+
+// grade returns the quality grade of segment.
+func (t QualityThresholds) grade(segment Segment) Quality {
+	var ratio float32
+	if segment.Windows > 0 {
+		ratio = float32(segment.VoicedWindows) / float32(segment.Windows)
+	}
+
+	switch {
+	case segment.MeanProbability >= t.HighMeanProbability && ratio >= t.HighVoicedRatio:
+		return QualityHigh
+	case segment.MeanProbability >= t.MediumMeanProbability && ratio >= t.MediumVoicedRatio:
+		return QualityMedium
+	default:
+		return QualityLow
+	}
+}
+
+// startSegment returns the segment starting at at, as just triggered by the window
+// with speechProb, accounting for the windows that led to the trigger but that last
+// one, which is added along with the following windows by addWindow.
+func (sd *Detector) startSegment(at float64, speechProb float32) Segment {
+	segment := Segment{
+		SpeechStartAt:   at,
+		VoicedWindows:   sd.cfg.TriggerWindows - 1,
+		Windows:         sd.cfg.TriggerWindows - 1,
+		PeakProbability: sd.speechRunPeak,
+		Unfinished:      true,
+	}
+	if segment.Windows > 0 {
+		segment.MeanProbability = float32((sd.speechRunProbSum - float64(speechProb)) / float64(segment.Windows))
+	}
+	return segment
+}
+
+// addWindow accounts for a window processed while segment is in progress.
+func (sd *Detector) addWindow(segment *Segment, speechProb float32) {
+	segment.Windows++
+	segment.MeanProbability += (speechProb - segment.MeanProbability) / float32(segment.Windows)
+	segment.PeakProbability = max(segment.PeakProbability, speechProb)
+	if speechProb >= sd.cfg.Threshold {
+		segment.VoicedWindows++
+	}
+}
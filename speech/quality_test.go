@@ -0,0 +1,66 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQualityThresholds(t *testing.T) {
+	t.Run("grade", func(t *testing.T) {
+		tcs := []struct {
+			name    string
+			segment Segment
+			quality Quality
+		}{
+			{
+				name:    "high",
+				segment: Segment{Windows: 10, VoicedWindows: 9, MeanProbability: 0.9},
+				quality: QualityHigh,
+			},
+			{
+				name:    "sparse",
+				segment: Segment{Windows: 10, VoicedWindows: 5, MeanProbability: 0.9},
+				quality: QualityMedium,
+			},
+			{
+				name:    "medium",
+				segment: Segment{Windows: 10, VoicedWindows: 9, MeanProbability: 0.7},
+				quality: QualityMedium,
+			},
+			{
+				name:    "low",
+				segment: Segment{Windows: 10, VoicedWindows: 3, MeanProbability: 0.9},
+				quality: QualityLow,
+			},
+			{
+				name:    "no windows",
+				segment: Segment{MeanProbability: 0.9},
+				quality: QualityLow,
+			},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.name, func(t *testing.T) {
+				require.Equal(t, tc.quality, DefaultQualityThresholds.grade(tc.segment))
+			})
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		require.NoError(t, DefaultQualityThresholds.validate())
+
+		thresholds := DefaultQualityThresholds
+		thresholds.HighVoicedRatio = 1.5
+		require.EqualError(t, thresholds.validate(), "invalid QualityThresholds: should be in range [0, 1]")
+
+		thresholds = DefaultQualityThresholds
+		thresholds.MediumMeanProbability = 0.9
+		require.EqualError(t, thresholds.validate(), "invalid QualityThresholds: medium thresholds should not exceed high ones")
+	})
+
+	t.Run("string", func(t *testing.T) {
+		require.Equal(t, "high", QualityHigh.String())
+		require.Equal(t, "Quality(0)", Quality(0).String())
+	})
+}
@@ -0,0 +1,193 @@
+package speech
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Segments is a list of speech segments as returned by Detect.
+type Segments []Segment
+
+// Count returns the number of segments.
+func (s Segments) Count() int {
+	return len(s)
+}
+
+// TotalSpeechDuration returns the sum of the durations, in seconds, of all
+// finished segments. Segments that have no end yet are not accounted for.
+func (s Segments) TotalSpeechDuration() float64 {
+	var total float64
+	for _, segment := range s {
+		if segment.Unfinished {
+			continue
+		}
+		total += segment.SpeechEndAt - segment.SpeechStartAt
+	}
+	return total
+}
+
+// Filter returns the segments for which keep returns true.
+func (s Segments) Filter(keep func(Segment) bool) Segments {
+	var filtered Segments
+	for _, segment := range s {
+		if keep(segment) {
+			filtered = append(filtered, segment)
+		}
+	}
+	return filtered
+}
+
+// segmentsBinaryVersion is the version of the encoding produced by MarshalBinary,
+// to be bumped on any change to it. Version 2 added the windows count, the mean and
+// peak probabilities and the quality grade.
+const segmentsBinaryVersion = 2
+
+// segmentFlagUnfinished is set in the flags of an encoded segment that is unfinished.
+const segmentFlagUnfinished = 1 << 0
+
+// minEncodedSegmentLen returns the length of a segment encoded as version without
+// envelope: the flags, the start and end timestamps, the voiced windows count, the
+// SNR, then as of version 2 the windows count, the mean and peak probabilities and the
+// quality, and finally the envelope length.
+func minEncodedSegmentLen(version byte) int {
+	if version < 2 {
+		return 1 + 8 + 8 + 1 + 4 + 1
+	}
+	return 1 + 8 + 8 + 1 + 4 + 1 + 4 + 4 + 1 + 1
+}
+
+// MarshalBinary encodes the segments into a compact binary form, e.g. to cache the
+// detection results of a file. The encoding starts with a version byte, so that data
+// produced by a later version of the format is rejected by UnmarshalBinary rather than
+// misread. It implements encoding.BinaryMarshaler.
+func (s Segments) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 1+binary.MaxVarintLen64+len(s)*minEncodedSegmentLen(segmentsBinaryVersion))
+	data = append(data, segmentsBinaryVersion)
+	data = binary.AppendUvarint(data, uint64(len(s)))
+
+	for _, segment := range s {
+		var flags byte
+		if segment.Unfinished {
+			flags |= segmentFlagUnfinished
+		}
+		data = append(data, flags)
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(segment.SpeechStartAt))
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(segment.SpeechEndAt))
+		data = binary.AppendUvarint(data, uint64(segment.VoicedWindows))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(segment.SNR))
+		data = binary.AppendUvarint(data, uint64(segment.Windows))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(segment.MeanProbability))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(segment.PeakProbability))
+		data = append(data, byte(segment.Quality))
+		data = binary.AppendUvarint(data, uint64(len(segment.Envelope)))
+		for _, v := range segment.Envelope {
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes segments encoded by MarshalBinary, replacing the content of
+// s. It implements encoding.BinaryUnmarshaler.
+func (s *Segments) UnmarshalBinary(data []byte) error {
+	if s == nil {
+		return fmt.Errorf("invalid nil segments")
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("invalid segments data: missing version")
+	}
+	// Data encoded by earlier versions of the format is still supported.
+	version := data[0]
+	if version < 1 || version > segmentsBinaryVersion {
+		return fmt.Errorf("unsupported segments data version %d", version)
+	}
+	data = data[1:]
+
+	uvarint := func(what string) (uint64, error) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid segments data: malformed %s", what)
+		}
+		data = data[n:]
+		return v, nil
+	}
+
+	count, err := uvarint("segment count")
+	if err != nil {
+		return err
+	}
+	if count > uint64(len(data)/minEncodedSegmentLen(version)) {
+		return fmt.Errorf("invalid segments data: truncated")
+	}
+
+	segments := make(Segments, count)
+	for i := range segments {
+		if len(data) < 1+8+8 {
+			return fmt.Errorf("invalid segments data: truncated")
+		}
+		segment := &segments[i]
+		segment.Unfinished = data[0]&segmentFlagUnfinished != 0
+		segment.SpeechStartAt = math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
+		segment.SpeechEndAt = math.Float64frombits(binary.LittleEndian.Uint64(data[9:17]))
+		data = data[17:]
+
+		voiced, err := uvarint("voiced windows")
+		if err != nil {
+			return err
+		}
+		if voiced > math.MaxInt32 {
+			return fmt.Errorf("invalid segments data: voiced windows out of range")
+		}
+		segment.VoicedWindows = int(voiced)
+
+		if len(data) < 4 {
+			return fmt.Errorf("invalid segments data: truncated")
+		}
+		segment.SNR = math.Float32frombits(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+
+		if version >= 2 {
+			windows, err := uvarint("windows")
+			if err != nil {
+				return err
+			}
+			if windows > math.MaxInt32 {
+				return fmt.Errorf("invalid segments data: windows out of range")
+			}
+			segment.Windows = int(windows)
+
+			if len(data) < 4+4+1 {
+				return fmt.Errorf("invalid segments data: truncated")
+			}
+			segment.MeanProbability = math.Float32frombits(binary.LittleEndian.Uint32(data[0:4]))
+			segment.PeakProbability = math.Float32frombits(binary.LittleEndian.Uint32(data[4:8]))
+			segment.Quality = Quality(data[8])
+			data = data[9:]
+		}
+
+		envelopeLen, err := uvarint("envelope length")
+		if err != nil {
+			return err
+		}
+		if envelopeLen > uint64(len(data)/4) {
+			return fmt.Errorf("invalid segments data: truncated")
+		}
+		if envelopeLen > 0 {
+			segment.Envelope = make([]float32, envelopeLen)
+			for j := range segment.Envelope {
+				segment.Envelope[j] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*j:]))
+			}
+			data = data[4*envelopeLen:]
+		}
+	}
+
+	if len(data) > 0 {
+		return fmt.Errorf("invalid segments data: %d trailing bytes", len(data))
+	}
+
+	*s = segments
+	return nil
+}
@@ -0,0 +1,112 @@
+package speech
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegments(t *testing.T) {
+	segments := Segments{
+		{
+			SpeechStartAt: 1.0,
+			SpeechEndAt:   1.5,
+		},
+		{
+			SpeechStartAt: 2.0,
+			SpeechEndAt:   4.0,
+		},
+		{
+			SpeechStartAt: 4.5,
+			SpeechEndAt:   0,
+			Unfinished:    true,
+		},
+	}
+
+	t.Run("count", func(t *testing.T) {
+		require.Equal(t, 3, segments.Count())
+		require.Equal(t, 0, Segments(nil).Count())
+	})
+
+	t.Run("total speech duration", func(t *testing.T) {
+		require.InDelta(t, 2.5, segments.TotalSpeechDuration(), 1e-9)
+		require.Zero(t, Segments(nil).TotalSpeechDuration())
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		long := segments.Filter(func(s Segment) bool {
+			return s.SpeechEndAt-s.SpeechStartAt > 1
+		})
+		require.Equal(t, Segments{
+			{
+				SpeechStartAt: 2.0,
+				SpeechEndAt:   4.0,
+			},
+		}, long)
+
+		require.Empty(t, segments.Filter(func(Segment) bool { return false }))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		withDetails := append(Segments{
+			{
+				SpeechStartAt:   0.25,
+				SpeechEndAt:     0.75,
+				VoicedWindows:   12,
+				Windows:         14,
+				MeanProbability: 0.75,
+				PeakProbability: 0.98,
+				Quality:         QualityHigh,
+				SNR:             18.5,
+				Envelope:        []float32{0.1, 0.4, 0.2},
+			},
+		}, segments...)
+
+		data, err := withDetails.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, byte(segmentsBinaryVersion), data[0])
+
+		var decoded Segments
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, withDetails, decoded)
+
+		data, err = Segments(nil).MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Empty(t, decoded)
+	})
+
+	t.Run("binary version 1", func(t *testing.T) {
+		// A single segment from 1.5 to 2 seconds, with 7 voiced windows, an SNR of 10
+		// and no envelope.
+		data := []byte{1, 1, 0}
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(1.5))
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(2))
+		data = append(data, 7)
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(10))
+		data = append(data, 0)
+
+		var decoded Segments
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, Segments{{
+			SpeechStartAt: 1.5,
+			SpeechEndAt:   2,
+			VoicedWindows: 7,
+			SNR:           10,
+		}}, decoded)
+	})
+
+	t.Run("binary invalid", func(t *testing.T) {
+		data, err := segments.MarshalBinary()
+		require.NoError(t, err)
+
+		var decoded Segments
+		require.EqualError(t, decoded.UnmarshalBinary(nil), "invalid segments data: missing version")
+		require.EqualError(t, decoded.UnmarshalBinary(append([]byte{3}, data[1:]...)), "unsupported segments data version 3")
+		require.EqualError(t, decoded.UnmarshalBinary(data[:len(data)-1]), "invalid segments data: truncated")
+		require.EqualError(t, decoded.UnmarshalBinary(append(data, 0)), "invalid segments data: 1 trailing bytes")
+		require.Nil(t, decoded)
+	})
+}
@@ -0,0 +1,68 @@
+package speech
+
+import "math"
+
+// estimateSNR sets the SNR of segments, detected over pcm starting at the given sample
+// position.
+//
+// The SNR of a segment is estimated as the ratio, in dB, of the mean energy of its
+// samples to the mean energy of the silence surrounding it: the samples between the end
+// of the previous segment (or the start of pcm) and its start, along with those between
+// its end and the start of the next segment (or the end of pcm). Since the energy of a
+// segment includes the noise in it, as well as the silence added by SpeechPadMs, the
+// estimate is only meaningful for stationary noise and is biased low for noisy audio.
+// It's zero when the segment has no surrounding silence or the silence is perfectly
+// quiet, e.g. digitally generated.
+func (sd *Detector) estimateSNR(segments []Segment, pcm []float32, start int) {
+	// sampleAt returns the index within pcm of the sample at the given timestamp.
+	sampleAt := func(at float64) int {
+		return min(max(int(at*float64(sd.cfg.SampleRate))-start, 0), len(pcm))
+	}
+
+	bounds := make([][2]int, len(segments))
+	for i, segment := range segments {
+		bounds[i][0] = sampleAt(segment.SpeechStartAt)
+		bounds[i][1] = len(pcm)
+		if !segment.Unfinished {
+			bounds[i][1] = max(sampleAt(segment.SpeechEndAt), bounds[i][0])
+		}
+	}
+
+	for i := range segments {
+		silenceStart, silenceEnd := 0, len(pcm)
+		if i > 0 {
+			silenceStart = bounds[i-1][1]
+		}
+		if i < len(segments)-1 {
+			silenceEnd = bounds[i+1][0]
+		}
+
+		var noise, noiseLen float64
+		if n := bounds[i][0] - silenceStart; n > 0 {
+			noise += energy(pcm[silenceStart:bounds[i][0]])
+			noiseLen += float64(n)
+		}
+		if n := silenceEnd - bounds[i][1]; n > 0 {
+			noise += energy(pcm[bounds[i][1]:silenceEnd])
+			noiseLen += float64(n)
+		}
+
+		signalLen := float64(bounds[i][1] - bounds[i][0])
+		if noise == 0 || signalLen == 0 {
+			segments[i].SNR = 0
+			continue
+		}
+
+		signal := energy(pcm[bounds[i][0]:bounds[i][1]])
+		segments[i].SNR = float32(10 * math.Log10((signal/signalLen)/(noise/noiseLen)))
+	}
+}
+
+// energy returns the sum of the squared samples.
+func energy(samples []float32) float64 {
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample) * float64(sample)
+	}
+	return sum
+}
@@ -0,0 +1,64 @@
+package speech
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSNR(t *testing.T) {
+	// tone returns n samples alternating between amplitude and -amplitude.
+	tone := func(n int, amplitude float32) []float32 {
+		samples := make([]float32, n)
+		for i := range samples {
+			samples[i] = amplitude
+			if i%2 == 1 {
+				samples[i] = -amplitude
+			}
+		}
+		return samples
+	}
+
+	sd := &Detector{cfg: DetectorConfig{SampleRate: 16000}}
+
+	t.Run("surrounding silence", func(t *testing.T) {
+		var pcm []float32
+		pcm = append(pcm, tone(16000, 0.01)...)
+		pcm = append(pcm, tone(16000, 0.1)...)
+		pcm = append(pcm, tone(16000, 0.001)...)
+		pcm = append(pcm, tone(16000, 0.5)...)
+
+		segments := []Segment{
+			{SpeechStartAt: 1, SpeechEndAt: 2},
+			{SpeechStartAt: 3, Unfinished: true},
+		}
+		sd.estimateSNR(segments, pcm, 0)
+
+		// The noise energy is averaged over the silence on both sides of the first
+		// segment, while the second one is only preceded by silence.
+		require.InDelta(t, 10*math.Log10(0.01/((0.0001+0.000001)/2)), segments[0].SNR, 1e-3)
+		require.InDelta(t, 10*math.Log10(0.25/0.000001), segments[1].SNR, 1e-3)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		pcm := append(tone(16000, 0.01), tone(16000, 0.1)...)
+
+		// The input starts after a second of previously processed audio.
+		segments := []Segment{{SpeechStartAt: 2, SpeechEndAt: 3}}
+		sd.estimateSNR(segments, pcm, 16000)
+		require.InDelta(t, 20, segments[0].SNR, 1e-3)
+	})
+
+	t.Run("no silence", func(t *testing.T) {
+		pcm := append(make([]float32, 16000), tone(16000, 0.1)...)
+
+		segments := []Segment{{SpeechStartAt: 1, SpeechEndAt: 2}}
+		sd.estimateSNR(segments, pcm, 0)
+		require.Zero(t, segments[0].SNR)
+
+		segments = []Segment{{SpeechStartAt: 0, SpeechEndAt: 2}}
+		sd.estimateSNR(segments, pcm, 0)
+		require.Zero(t, segments[0].SNR)
+	})
+}
@@ -0,0 +1,428 @@
+package speech
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// StreamCallbacks contains optional functions called as speech is detected
+// by a StreamDetector.
+type StreamCallbacks struct {
+	// Called as soon as a speech segment begins. The segment has no end yet.
+	OnSpeechStart func(Segment)
+	// Called while a speech segment is in progress, every MaxHoldMs since the segment
+	// started, with the segment so far: it's unfinished but its SpeechEndAt is the
+	// position reached. It bounds the time between notifications during long segments,
+	// e.g. to forward partial speech to a live transcription. Requires MaxHoldMs.
+	OnSpeechPartial func(Segment)
+	// Called when a speech segment is finalized. Returning false stops the detection:
+	// the rest of the chunk being processed is discarded and further chunks are ignored
+	// until Reset is called.
+	OnSpeechEnd func(Segment) bool
+}
+
+// StreamDetector detects speech over an indefinite stream of audio, fed in chunks
+// of any size. Unlike Detect, which needs the whole input at once, it only retains
+// the samples of the window currently being filled, so memory usage stays constant
+// regardless of the stream length.
+//
+// Samples are buffered so that windows are always aligned on the start of the stream,
+// which guarantees that the emitted segments are the same however the stream is split
+// into chunks, and match the finished segments Detect returns for the whole input. The
+// exception is input whose length is a multiple of the window size: Detect skips the
+// final window, ending exactly at the end of the input, while the stream processes it,
+// so that the two may differ at the end.
+type StreamDetector struct {
+	sd        *Detector
+	callbacks StreamCallbacks
+
+	// Fixed capacity buffer holding the samples of the window being filled.
+	window []float32
+
+	// The segment currently in progress, if any.
+	current Segment
+	open    bool
+	// The position in samples of the last notification of the segment in progress,
+	// when calling OnSpeechPartial.
+	lastNotified int
+
+	// Whether detection was stopped by the OnSpeechEnd callback.
+	stopped bool
+}
+
+// NewStreamDetector creates a detector for streaming audio. The PadShortInput
+// setting doesn't apply to streams and is ignored.
+func NewStreamDetector(cfg DetectorConfig, callbacks StreamCallbacks) (*StreamDetector, error) {
+	sd, err := NewDetector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamDetector{
+		sd:        sd,
+		callbacks: callbacks,
+		window:    make([]float32, 0, sd.windowSize()),
+	}, nil
+}
+
+// Process feeds a chunk of audio to the detector and returns the segments finalized
+// while processing it, if any. Trailing samples not filling a whole window are retained
+// and processed along with the next chunk. Once stopped by the OnSpeechEnd callback,
+// Process returns no segments until Reset is called.
+func (s *StreamDetector) Process(pcm []float32) ([]Segment, error) {
+	if s == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if s.stopped {
+		return nil, nil
+	}
+
+	var segments []Segment
+	err := s.fillWindows(s.sd.prepareInput(pcm), func(window []float32) (bool, error) {
+		segment, ok, err := s.processWindow(window)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			segments = append(segments, segment)
+		}
+
+		return !s.stopped, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// fillWindows appends pcm to the window being filled, calling fn with each completed
+// window until it returns false, after which the remaining samples are discarded.
+func (s *StreamDetector) fillWindows(pcm []float32, fn func(window []float32) (bool, error)) error {
+	for len(pcm) > 0 {
+		n := copy(s.window[len(s.window):cap(s.window)], pcm)
+		s.window = s.window[:len(s.window)+n]
+		pcm = pcm[n:]
+
+		if len(s.window) < cap(s.window) {
+			break
+		}
+
+		more, err := fn(s.window)
+		s.window = s.window[:0]
+		if err != nil {
+			return err
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return nil
+}
+
+// processWindow runs detection over a full window, returning the segment it
+// finalized, if any.
+func (s *StreamDetector) processWindow(window []float32) (Segment, bool, error) {
+	speechProb, err := s.sd.inferWindow(window)
+	if err != nil {
+		return Segment{}, false, err
+	}
+
+	event, at := s.sd.step(speechProb)
+
+	if event == speechEventStart {
+		s.current = s.sd.startSegment(at, speechProb)
+		s.open = true
+	}
+
+	if s.sd.triggered && s.open {
+		s.sd.addWindow(&s.current, speechProb)
+	}
+
+	if event == speechEventStart {
+		s.lastNotified = s.sd.currSample
+		if s.callbacks.OnSpeechStart != nil {
+			s.callbacks.OnSpeechStart(s.sd.outputSegment(s.current))
+		}
+	}
+
+	if event != speechEventEnd {
+		if s.open && s.sd.cfg.MaxHoldMs > 0 && s.callbacks.OnSpeechPartial != nil &&
+			(s.sd.currSample-s.lastNotified)*1000 >= s.sd.cfg.MaxHoldMs*s.sd.cfg.SampleRate {
+			s.lastNotified = s.sd.currSample
+			s.callbacks.OnSpeechPartial(s.partialSegment())
+		}
+		return Segment{}, false, nil
+	}
+
+	segment := s.current
+	segment.SpeechEndAt = max(at, segment.SpeechStartAt)
+	segment.Unfinished = false
+	s.current = Segment{}
+	s.open = false
+
+	if s.sd.tooShort(segment) {
+		slog.Debug("filtered out short speech segment",
+			slog.Float64("startAt", segment.SpeechStartAt),
+			slog.Float64("endAt", segment.SpeechEndAt),
+			slog.Float64("duration", segment.SpeechEndAt-segment.SpeechStartAt),
+			slog.Int("minDuration", s.sd.cfg.MinSpeechDurationMs))
+		return Segment{}, false, nil
+	}
+
+	segment = s.sd.outputSegment(segment)
+	if s.sd.cfg.SegmentFilter != nil {
+		var keep bool
+		if segment, keep = s.sd.cfg.SegmentFilter(segment); !keep {
+			return Segment{}, false, nil
+		}
+	}
+
+	if s.callbacks.OnSpeechEnd != nil && !s.callbacks.OnSpeechEnd(segment) {
+		s.stopped = true
+	}
+
+	return segment, true, nil
+}
+
+// partialSegment returns the segment in progress as passed to OnSpeechPartial, ending
+// at the current position.
+func (s *StreamDetector) partialSegment() Segment {
+	segment := s.current
+	segment.SpeechEndAt = max(float64(s.sd.currSample)/float64(s.sd.cfg.SampleRate), segment.SpeechStartAt)
+	segment.Unfinished = false
+	segment = s.sd.outputSegment(segment)
+	segment.Unfinished = true
+	return segment
+}
+
+// WindowSize returns the number of samples of the windows the stream is split into, see
+// Detector.WindowSize.
+func (s *StreamDetector) WindowSize() int {
+	return s.sd.WindowSize()
+}
+
+// HopSize returns the number of samples between the starts of consecutive windows, see
+// Detector.HopSize.
+func (s *StreamDetector) HopSize() int {
+	return s.sd.HopSize()
+}
+
+// IsTriggered reports whether a speech segment is currently in progress.
+func (s *StreamDetector) IsTriggered() bool {
+	return s != nil && s.open && !s.stopped
+}
+
+// Pending returns the speech segment currently in progress, if any, without finalizing
+// it, e.g. to display the start and elapsed duration of an ongoing utterance. As with
+// OnSpeechPartial, the segment is unfinished but its SpeechEndAt is the position
+// reached. It returns false if no segment is in progress.
+func (s *StreamDetector) Pending() (Segment, bool) {
+	if !s.IsTriggered() {
+		return Segment{}, false
+	}
+
+	return s.partialSegment(), true
+}
+
+// Flush ends the current stream, returning the segment in progress, if any, as an
+// unfinished segment. Buffered samples not filling a whole window are discarded. The
+// detector is then reset, ready to be used on a new stream.
+func (s *StreamDetector) Flush() ([]Segment, error) {
+	if s == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	var segments []Segment
+	if s.open && !s.stopped {
+		segments = append(segments, s.sd.outputSegment(s.current))
+	}
+
+	if err := s.Reset(); err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// DetectChan runs detection over the chunks of audio received from in, in a separate
+// goroutine, sending the finalized segments to the returned segment channel. When in
+// is closed, the segment in progress, if any, is flushed and the segment channel is
+// closed, after which the detector is ready to be used on a new stream.
+//
+// Processing stops at the first error, which is sent to the returned error channel;
+// chunks received from in afterwards are discarded. The error channel is closed along
+// with the segment channel, which callers must drain for processing to make progress.
+// The detector must not be used otherwise until the segment channel is closed.
+func (s *StreamDetector) DetectChan(in <-chan []float32) (<-chan Segment, <-chan error) {
+	out := make(chan Segment)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		var failed bool
+		fail := func(err error) {
+			failed = true
+			errc <- err
+		}
+
+		for chunk := range in {
+			if failed {
+				continue
+			}
+
+			segments, err := s.Process(chunk)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			for _, segment := range segments {
+				out <- segment
+			}
+		}
+
+		if failed {
+			return
+		}
+
+		segments, err := s.Flush()
+		if err != nil {
+			fail(err)
+			return
+		}
+		for _, segment := range segments {
+			out <- segment
+		}
+	}()
+
+	return out, errc
+}
+
+// ProbStream runs inference over the chunks of audio received from in, in a separate
+// goroutine, sending the speech probability of each window to the returned channel as
+// soon as the window is complete, e.g. to drive a live speech probability meter. The
+// samples are buffered into windows as by Process, but no segmentation is done and the
+// callbacks aren't called. When in is closed, the trailing samples not filling a whole
+// window are discarded, the detector is reset and the probability channel is closed.
+//
+// Errors are reported as by DetectChan, and the detector must not be used otherwise
+// until the probability channel is closed.
+func (s *StreamDetector) ProbStream(in <-chan []float32) (<-chan float32, <-chan error) {
+	out := make(chan float32)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		var failed bool
+		fail := func(err error) {
+			failed = true
+			errc <- err
+		}
+
+		for chunk := range in {
+			if failed {
+				continue
+			}
+
+			err := s.fillWindows(s.sd.prepareInput(chunk), func(window []float32) (bool, error) {
+				speechProb, err := s.sd.inferWindow(window)
+				if err != nil {
+					return false, err
+				}
+				out <- speechProb
+				return true, nil
+			})
+			if err != nil {
+				fail(err)
+			}
+		}
+
+		if failed {
+			return
+		}
+
+		if err := s.Reset(); err != nil {
+			fail(err)
+		}
+	}()
+
+	return out, errc
+}
+
+// SetSampleRate switches the stream to sampleRate, e.g. after the audio source was
+// renegotiated, and returns the segment in progress, if any, as an unfinished segment.
+// The samples buffered at the previous rate are discarded and the detection state is
+// reset, as the model state doesn't carry over across sample rates, but the timeline
+// is preserved: the timestamps of the following segments keep counting from the
+// duration streamed so far. A stream stopped by the OnSpeechEnd callback stays stopped.
+// Setting the current sample rate has no effect.
+func (s *StreamDetector) SetSampleRate(sampleRate int) ([]Segment, error) {
+	if s == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if !slices.Contains(supportedSampleRates, sampleRate) {
+		return nil, fmt.Errorf("invalid sample rate: valid values are 8000 and 16000")
+	}
+	if highPassHz := s.sd.cfg.HighPassHz; highPassHz > 0 && highPassHz*2 >= float32(sampleRate) {
+		return nil, fmt.Errorf("invalid sample rate: should be more than twice the HighPassHz")
+	}
+
+	oldRate := s.sd.cfg.SampleRate
+	if sampleRate == oldRate {
+		return nil, nil
+	}
+
+	var segments []Segment
+	if s.open && !s.stopped {
+		segments = append(segments, s.sd.outputSegment(s.current))
+	}
+
+	// The buffered samples are dropped but still account for the elapsed time.
+	elapsed := s.sd.rateChangeOffsetSec + float64(s.sd.currSample+len(s.window))/float64(oldRate)
+	stopped := s.stopped
+	if err := s.Reset(); err != nil {
+		return nil, err
+	}
+	s.sd.rateChangeOffsetSec = elapsed
+	s.stopped = stopped
+
+	s.sd.setSampleRate(sampleRate)
+	s.window = make([]float32, 0, s.sd.windowSize())
+
+	return segments, nil
+}
+
+// Reset clears the detection state, including any buffered samples, so that
+// the detector can be used on a new stream.
+func (s *StreamDetector) Reset() error {
+	if s == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	s.window = s.window[:0]
+	s.current = Segment{}
+	s.open = false
+	s.lastNotified = 0
+	s.stopped = false
+
+	return s.sd.Reset()
+}
+
+// Destroy releases the resources held by the detector.
+func (s *StreamDetector) Destroy() error {
+	if s == nil {
+		return fmt.Errorf("invalid nil detector")
+	}
+
+	return s.sd.Destroy()
+}
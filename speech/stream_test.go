@@ -0,0 +1,418 @@
+package speech
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamDetector(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	detected, err := sd.Detect(samples)
+	require.NoError(t, err)
+	require.NotEmpty(t, detected)
+
+	// Only finished segments are emitted by the stream detector.
+	var expected []Segment = Segments(detected).Filter(func(s Segment) bool {
+		return !s.Unfinished
+	})
+	require.NotEmpty(t, expected)
+
+	t.Run("chunked processing", func(t *testing.T) {
+		var started, ended []Segment
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{
+			OnSpeechStart: func(s Segment) {
+				started = append(started, s)
+			},
+			OnSpeechEnd: func(s Segment) bool {
+				ended = append(ended, s)
+				return true
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		// Chunks not aligned to the window size.
+		var segments []Segment
+		for i := 0; i < len(samples); i += 1000 {
+			chunk, err := stream.Process(samples[i:min(i+1000, len(samples))])
+			require.NoError(t, err)
+			segments = append(segments, chunk...)
+		}
+
+		require.Equal(t, expected, segments)
+		require.Equal(t, expected, ended)
+		require.GreaterOrEqual(t, len(started), len(detected))
+		for _, s := range started {
+			require.Zero(t, s.SpeechEndAt)
+		}
+	})
+
+	t.Run("chunk boundaries", func(t *testing.T) {
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		for _, chunkSize := range []int{1, 100, 511, 512, 513, 4096, len(samples)} {
+			err := stream.Reset()
+			require.NoError(t, err)
+
+			var segments []Segment
+			for i := 0; i < len(samples); i += chunkSize {
+				chunk, err := stream.Process(samples[i:min(i+chunkSize, len(samples))])
+				require.NoError(t, err)
+				segments = append(segments, chunk...)
+			}
+
+			require.Equal(t, expected, segments, "chunk size %d", chunkSize)
+		}
+
+		// Chunks of varying sizes.
+		err = stream.Reset()
+		require.NoError(t, err)
+
+		var segments []Segment
+		for i, n := 0, 1; i < len(samples); i, n = i+n, n*7%1999+1 {
+			chunk, err := stream.Process(samples[i:min(i+n, len(samples))])
+			require.NoError(t, err)
+			segments = append(segments, chunk...)
+		}
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		_, err = stream.Process(samples[:len(samples)/2])
+		require.NoError(t, err)
+
+		err = stream.Reset()
+		require.NoError(t, err)
+
+		segments, err := stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var ended []Segment
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{
+			OnSpeechEnd: func(s Segment) bool {
+				ended = append(ended, s)
+				return false
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		segments, err := stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected[:1], segments)
+		require.Equal(t, expected[:1], ended)
+
+		// Further chunks are ignored until the detector is reset.
+		segments, err = stream.Process(samples)
+		require.NoError(t, err)
+		require.Empty(t, segments)
+		require.Len(t, ended, 1)
+
+		err = stream.Reset()
+		require.NoError(t, err)
+
+		segments, err = stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected[:1], segments)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		segments, err := stream.Process(samples)
+		require.NoError(t, err)
+		flushed, err := stream.Flush()
+		require.NoError(t, err)
+
+		// Flushing emits the segments Detect reports as unfinished.
+		var all []Segment = append(segments, flushed...)
+		require.Equal(t, detected, all)
+
+		// The detector is reset.
+		segments, err = stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("detect chan", func(t *testing.T) {
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		in := make(chan []float32)
+		go func() {
+			defer close(in)
+			for i := 0; i < len(samples); i += 1000 {
+				in <- samples[i:min(i+1000, len(samples))]
+			}
+		}()
+
+		out, errc := stream.DetectChan(in)
+		var segments []Segment
+		for segment := range out {
+			segments = append(segments, segment)
+		}
+		require.NoError(t, <-errc)
+		require.Equal(t, detected, segments)
+	})
+
+	t.Run("set sample rate", func(t *testing.T) {
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		_, err = stream.SetSampleRate(44100)
+		require.EqualError(t, err, "invalid sample rate: valid values are 8000 and 16000")
+
+		segments, err := stream.SetSampleRate(16000)
+		require.NoError(t, err)
+		require.Empty(t, segments)
+
+		segments, err = stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// The segment in progress is returned as by Flush.
+		flushed, err := stream.SetSampleRate(8000)
+		require.NoError(t, err)
+		var all []Segment = append(segments, flushed...)
+		require.Equal(t, detected, all)
+		require.Equal(t, 8000, stream.sd.Config().SampleRate)
+
+		downsampled := make([]float32, len(samples)/2)
+		for i := range downsampled {
+			downsampled[i] = samples[2*i]
+		}
+
+		sd8k, err := NewDetector(DetectorConfig{
+			ModelPath:      cfg.ModelPath,
+			SampleRate:     8000,
+			Threshold:      cfg.Threshold,
+			StartOffsetSec: float64(len(samples)) / 16000,
+		})
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sd8k.Destroy())
+		}()
+
+		detected8k, err := sd8k.Detect(downsampled)
+		require.NoError(t, err)
+
+		// The timestamps keep counting from the audio streamed at 16000 Hz.
+		segments, err = stream.Process(downsampled)
+		require.NoError(t, err)
+		flushed, err = stream.Flush()
+		require.NoError(t, err)
+		require.Equal(t, detected8k, append(segments, flushed...))
+	})
+
+	t.Run("max hold", func(t *testing.T) {
+		cfg := cfg
+		cfg.MaxHoldMs = 200
+
+		var started, partials []Segment
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{
+			OnSpeechStart: func(s Segment) {
+				started = append(started, s)
+			},
+			OnSpeechPartial: func(s Segment) {
+				partials = append(partials, s)
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		segments, err := stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+		require.NotEmpty(t, partials)
+
+		// Partials extend the started segments, every MaxHoldMs rounded up to a window.
+		windowSec := 512.0 / 16000
+		for i, partial := range partials {
+			require.True(t, partial.Unfinished)
+			require.True(t, slices.ContainsFunc(started, func(s Segment) bool {
+				return s.SpeechStartAt == partial.SpeechStartAt
+			}))
+			require.Greater(t, partial.SpeechEndAt, partial.SpeechStartAt)
+			if i > 0 && partials[i-1].SpeechStartAt == partial.SpeechStartAt {
+				gap := partial.SpeechEndAt - partials[i-1].SpeechEndAt
+				require.GreaterOrEqual(t, gap, 0.2-1e-9)
+				require.Less(t, gap, 0.2+windowSec)
+			}
+		}
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		var start float64
+		var open bool
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{
+			OnSpeechStart: func(s Segment) {
+				start = s.SpeechStartAt
+				open = true
+			},
+			OnSpeechEnd: func(Segment) bool {
+				open = false
+				return true
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		_, ok := stream.Pending()
+		require.False(t, ok)
+		require.False(t, stream.IsTriggered())
+
+		var segments []Segment
+		var seenPending bool
+		for i := 0; i < len(samples); i += 512 {
+			chunk, err := stream.Process(samples[i:min(i+512, len(samples))])
+			require.NoError(t, err)
+			segments = append(segments, chunk...)
+
+			pending, ok := stream.Pending()
+			require.Equal(t, open, ok)
+			require.Equal(t, open, stream.IsTriggered())
+			if !ok {
+				require.Zero(t, pending)
+				continue
+			}
+			seenPending = true
+			require.True(t, pending.Unfinished)
+			require.Equal(t, start, pending.SpeechStartAt)
+			require.GreaterOrEqual(t, pending.SpeechEndAt, pending.SpeechStartAt)
+			require.LessOrEqual(t, pending.SpeechEndAt, float64(i+512)/16000)
+
+			// Reading the pending segment doesn't alter it.
+			again, _ := stream.Pending()
+			require.Equal(t, pending, again)
+		}
+		require.True(t, seenPending)
+		require.Equal(t, expected, segments)
+
+		_, err = stream.Flush()
+		require.NoError(t, err)
+		_, ok = stream.Pending()
+		require.False(t, ok)
+		require.False(t, stream.IsTriggered())
+	})
+
+	t.Run("prob stream", func(t *testing.T) {
+		require.NoError(t, sd.Reset())
+		_, expectedProbs, err := sd.DetectDetailed(samples)
+		require.NoError(t, err)
+
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		in := make(chan []float32)
+		go func() {
+			defer close(in)
+			for i := 0; i < len(samples); i += 1000 {
+				in <- samples[i:min(i+1000, len(samples))]
+			}
+		}()
+
+		out, errc := stream.ProbStream(in)
+		var probs []float32
+		for prob := range out {
+			probs = append(probs, prob)
+		}
+		require.NoError(t, <-errc)
+		require.Equal(t, expectedProbs, probs)
+
+		// The detector is reset once the input is closed.
+		segments, err := stream.Process(samples)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("window aligned input", func(t *testing.T) {
+		// Detect skips a final window ending exactly at the end of the input, which
+		// the stream processes.
+		pcm := samples[:100*sd.WindowSize()]
+
+		require.NoError(t, sd.Reset())
+		_, expectedProbs, err := sd.DetectDetailed(pcm)
+		require.NoError(t, err)
+		require.Len(t, expectedProbs, 99)
+
+		stream, err := NewStreamDetector(cfg, StreamCallbacks{})
+		require.NoError(t, err)
+		require.NotNil(t, stream)
+		defer func() {
+			require.NoError(t, stream.Destroy())
+		}()
+
+		in := make(chan []float32, 1)
+		in <- pcm
+		close(in)
+
+		out, errc := stream.ProbStream(in)
+		var probs []float32
+		for prob := range out {
+			probs = append(probs, prob)
+		}
+		require.NoError(t, <-errc)
+		require.Len(t, probs, 100)
+		require.Equal(t, expectedProbs, probs[:99])
+	})
+}
@@ -0,0 +1,51 @@
+package speech
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The text of the cues generated for speech segments.
+const cueText = "Speech"
+
+// SegmentsToSRT formats the finished segments as SubRip (SRT) cues. Unfinished
+// segments are omitted.
+func SegmentsToSRT(segments []Segment) string {
+	var b strings.Builder
+	n := 0
+	for _, segment := range segments {
+		if segment.Unfinished {
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", n,
+			formatCueTimestamp(segment.SpeechStartAt, ','),
+			formatCueTimestamp(segment.SpeechEndAt, ','),
+			cueText)
+	}
+	return b.String()
+}
+
+// SegmentsToVTT formats the finished segments as a WebVTT document. Unfinished
+// segments are omitted.
+func SegmentsToVTT(segments []Segment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, segment := range segments {
+		if segment.Unfinished {
+			continue
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatCueTimestamp(segment.SpeechStartAt, '.'),
+			formatCueTimestamp(segment.SpeechEndAt, '.'),
+			cueText)
+	}
+	return b.String()
+}
+
+// formatCueTimestamp formats sec as hh:mm:ss followed by sep and milliseconds.
+func formatCueTimestamp(sec float64, sep byte) string {
+	ms := int64(math.Round(max(sec, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
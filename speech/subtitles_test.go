@@ -0,0 +1,37 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtitles(t *testing.T) {
+	segments := []Segment{
+		{
+			SpeechStartAt: 1.0,
+			SpeechEndAt:   1.5,
+		},
+		{
+			SpeechStartAt: 3661.0125,
+			SpeechEndAt:   3662.9996,
+		},
+		{
+			SpeechStartAt: 4000,
+			Unfinished:    true,
+		},
+	}
+
+	t.Run("srt", func(t *testing.T) {
+		require.Equal(t, "1\n00:00:01,000 --> 00:00:01,500\nSpeech\n\n"+
+			"2\n01:01:01,013 --> 01:01:03,000\nSpeech\n\n", SegmentsToSRT(segments))
+		require.Empty(t, SegmentsToSRT(nil))
+	})
+
+	t.Run("vtt", func(t *testing.T) {
+		require.Equal(t, "WEBVTT\n\n"+
+			"00:00:01.000 --> 00:00:01.500\nSpeech\n\n"+
+			"01:01:01.013 --> 01:01:03.000\nSpeech\n\n", SegmentsToVTT(segments))
+		require.Equal(t, "WEBVTT\n\n", SegmentsToVTT(nil))
+	})
+}
@@ -0,0 +1,99 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DetectionSummary summarizes the result of running speech detection over an input.
+type DetectionSummary struct {
+	// The number of segments detected, including an unfinished one.
+	SegmentCount int
+	// The duration of the input in seconds.
+	AudioDuration float64
+	// The total duration of speech in seconds, an unfinished segment lasting until the
+	// end of the input. Overlapping segments are only counted once.
+	SpeechDuration float64
+	// The total duration of the input outside of segments, in seconds.
+	SilenceDuration float64
+	// The fraction of the input covered by segments, in the [0, 1] range.
+	SpeechRatio float64
+	// The time in seconds from the start of the input to the onset of the first segment,
+	// e.g. to measure the latency of a voice assistant user, or zero if no segment was
+	// detected. The onset is the start of the speech windows that triggered the segment,
+	// regardless of the SpeechPadMs padding and of the adjustments of its start, such as
+	// by OnsetBackoffWindows or FrameAlignMs. For streams, the first call of
+	// StreamCallbacks.OnSpeechStart signals the first speech as it's detected.
+	TimeToFirstSpeech float64
+}
+
+// DetectWithSummary works like Detect but also returns a summary of the segments
+// detected over pcm. Durations are accumulated as sample counts and converted to
+// seconds once, so that they add up exactly to the duration of the input.
+func (sd *Detector) DetectWithSummary(pcm []float32) ([]Segment, DetectionSummary, error) {
+	if sd == nil {
+		return nil, DetectionSummary{}, fmt.Errorf("invalid nil detector")
+	}
+
+	callStart := sd.currSample
+	segments, err := sd.Detect(pcm)
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, DetectionSummary{}, err
+	}
+
+	return segments, sd.summarize(segments, sd.onsets, callStart, len(pcm)), err
+}
+
+// summarize returns the summary of segments, detected over an input of n samples
+// starting at the given sample position, onsets holding the position of the onset of
+// each segment as recorded during detection.
+func (sd *Detector) summarize(segments []Segment, onsets []int, start, n int) DetectionSummary {
+	rate := float64(sd.cfg.SampleRate)
+	// sampleAt returns the position, relative to the input, of the sample at the given
+	// timestamp.
+	sampleAt := func(at float64) int {
+		return min(max(int(math.Round((at-sd.cfg.StartOffsetSec)*rate))-start, 0), n)
+	}
+
+	// Padded segments may overlap, in which case they are merged as by
+	// ExtractSpeechOnly, so that no sample is counted twice.
+	var speechSamples int
+	// The bounds of the merged region being accumulated, none while from is negative.
+	from, to := -1, -1
+	for _, segment := range segments {
+		start, end := sampleAt(segment.SpeechStartAt), n
+		if !segment.Unfinished {
+			end = sampleAt(segment.SpeechEndAt)
+		}
+		if end <= start {
+			continue
+		}
+
+		if from >= 0 && start <= to {
+			to = max(to, end)
+			continue
+		}
+		if from >= 0 {
+			speechSamples += to - from
+		}
+		from, to = start, end
+	}
+	if from >= 0 {
+		speechSamples += to - from
+	}
+
+	summary := DetectionSummary{
+		SegmentCount:    len(segments),
+		AudioDuration:   float64(n) / rate,
+		SpeechDuration:  float64(speechSamples) / rate,
+		SilenceDuration: float64(n-speechSamples) / rate,
+	}
+	if n > 0 {
+		summary.SpeechRatio = float64(speechSamples) / float64(n)
+	}
+	if len(segments) > 0 && len(onsets) > 0 {
+		summary.TimeToFirstSpeech = float64(min(max(onsets[0]-start, 0), n)) / rate
+	}
+	return summary
+}
@@ -0,0 +1,73 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	sd := &Detector{cfg: DetectorConfig{SampleRate: 16000}}
+
+	t.Run("segments", func(t *testing.T) {
+		segments := []Segment{
+			{SpeechStartAt: 0.5, SpeechEndAt: 1.25},
+			{SpeechStartAt: 3, Unfinished: true},
+		}
+
+		summary := sd.summarize(segments, []int{8000, 48000}, 0, 4*16000)
+		require.Equal(t, DetectionSummary{
+			SegmentCount:      2,
+			AudioDuration:     4,
+			SpeechDuration:    1.75,
+			SilenceDuration:   2.25,
+			SpeechRatio:       0.4375,
+			TimeToFirstSpeech: 0.5,
+		}, summary)
+	})
+
+	t.Run("time to first speech", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 16000, SpeechPadMs: 100, StartOffsetSec: 10}}
+
+		// The onset is taken as recorded, relative to the input, regardless of the
+		// padded start of the segment.
+		segments := []Segment{{SpeechStartAt: 12.4, SpeechEndAt: 13}}
+		summary := sd.summarize(segments, []int{2 * 16000}, 16000, 4*16000)
+		require.InDelta(t, 1, summary.TimeToFirstSpeech, 1e-9)
+
+		// The onset doesn't precede the start of the input.
+		summary = sd.summarize(segments, []int{8000}, 16000, 4*16000)
+		require.Zero(t, summary.TimeToFirstSpeech)
+	})
+
+	t.Run("overlapping segments", func(t *testing.T) {
+		// Segments padded by more than half the silence between them overlap.
+		segments := []Segment{
+			{SpeechStartAt: 0.5, SpeechEndAt: 1.5},
+			{SpeechStartAt: 1.25, SpeechEndAt: 2},
+			{SpeechStartAt: 1.75, Unfinished: true},
+		}
+
+		summary := sd.summarize(segments, []int{12000, 24000, 32000}, 0, 4*16000)
+		require.Equal(t, 3.5, summary.SpeechDuration)
+		require.Equal(t, 0.5, summary.SilenceDuration)
+		require.Equal(t, 0.875, summary.SpeechRatio)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		sd := &Detector{cfg: DetectorConfig{SampleRate: 16000, StartOffsetSec: 10}}
+
+		// The input starts after a second of previously processed audio, and segments
+		// extending past it are clipped.
+		segments := []Segment{{SpeechStartAt: 11.5, SpeechEndAt: 13}}
+		summary := sd.summarize(segments, []int{24000}, 16000, 16000)
+		require.Equal(t, 1.0, summary.AudioDuration)
+		require.Equal(t, 0.5, summary.SpeechDuration)
+		require.Equal(t, 0.5, summary.SilenceDuration)
+		require.Equal(t, 0.5, summary.SpeechRatio)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, DetectionSummary{}, sd.summarize(nil, nil, 0, 0))
+	})
+}
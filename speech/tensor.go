@@ -0,0 +1,124 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+)
+
+// TensorElementType is the element type of the audio, state and output tensors
+// of the model.
+type TensorElementType int
+
+const (
+	// 32-bit floating point tensors, as used by the stock Silero VAD models.
+	// Dynamically quantized (int8) models keep float32 inputs and outputs and
+	// should use this type as well.
+	TensorElementTypeFloat32 TensorElementType = iota + 1
+	// 16-bit floating point tensors, as used by models converted to half precision.
+	TensorElementTypeFloat16
+)
+
+func (t TensorElementType) ortType() C.ONNXTensorElementDataType {
+	if t == TensorElementTypeFloat16 {
+		return C.ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT16
+	}
+	return C.ONNX_TENSOR_ELEMENT_DATA_TYPE_FLOAT
+}
+
+// encodeTensor returns the data of values encoded as t. For float32 tensors the
+// returned memory is shared with values, otherwise buf is used to hold the encoded
+// data, growing it as needed.
+func encodeTensor(t TensorElementType, values []float32, buf *[]byte) []byte {
+	if t == TensorElementTypeFloat16 {
+		if cap(*buf) < len(values)*2 {
+			*buf = make([]byte, len(values)*2)
+		}
+		*buf = (*buf)[:len(values)*2]
+		for i, v := range values {
+			binary.NativeEndian.PutUint16((*buf)[i*2:], float32ToFloat16(v))
+		}
+		return *buf
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*4)
+}
+
+// decodeTensor fills dst with the values pointed by data, encoded as t.
+func decodeTensor(t TensorElementType, data unsafe.Pointer, dst []float32) {
+	if t == TensorElementTypeFloat16 {
+		for i, v := range unsafe.Slice((*uint16)(data), len(dst)) {
+			dst[i] = float16ToFloat32(v)
+		}
+		return
+	}
+	copy(dst, unsafe.Slice((*float32)(data), len(dst)))
+}
+
+// float32ToFloat16 converts f to the IEEE 754 half precision format, rounding to
+// the nearest even value.
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff > 0x7f800000:
+		// NaN
+		return sign | 0x7e00
+	case exp >= 0x1f:
+		// Overflow or infinity
+		return sign | 0x7c00
+	case exp <= 0:
+		// Subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - exp)
+		half := uint16(mant >> shift)
+		rem := mant & (1<<shift - 1)
+		mid := uint32(1) << (shift - 1)
+		if rem > mid || (rem == mid && half&1 == 1) {
+			half++
+		}
+		return sign | half
+	}
+
+	half := uint16(exp)<<10 | uint16(mant>>13)
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		// Carrying into the exponent correctly rounds up to the next power of two or infinity.
+		half++
+	}
+	return sign | half
+}
+
+// float16ToFloat32 converts an IEEE 754 half precision value to float32.
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		// Infinity or NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal, normalize it.
+		exp = 127 - 15 + 1
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		return math.Float32frombits(sign | exp<<23 | (mant&0x3ff)<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
@@ -0,0 +1,55 @@
+package speech
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat16Conversion(t *testing.T) {
+	tcs := []struct {
+		name string
+		f    float32
+		h    uint16
+	}{
+		{name: "zero", f: 0, h: 0x0000},
+		{name: "negative zero", f: float32(math.Copysign(0, -1)), h: 0x8000},
+		{name: "one", f: 1, h: 0x3c00},
+		{name: "negative two", f: -2, h: 0xc000},
+		{name: "half", f: 0.5, h: 0x3800},
+		{name: "max", f: 65504, h: 0x7bff},
+		{name: "smallest normal", f: 6.103515625e-05, h: 0x0400},
+		{name: "smallest subnormal", f: 5.960464477539063e-08, h: 0x0001},
+		{name: "largest subnormal", f: 6.097555160522461e-05, h: 0x03ff},
+		{name: "infinity", f: float32(math.Inf(1)), h: 0x7c00},
+		{name: "negative infinity", f: float32(math.Inf(-1)), h: 0xfc00},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.h, float32ToFloat16(tc.f))
+			require.Equal(t, math.Float32bits(tc.f), math.Float32bits(float16ToFloat32(tc.h)))
+		})
+	}
+
+	t.Run("rounding", func(t *testing.T) {
+		// Halfway between 1 and the next representable value rounds to even.
+		require.Equal(t, uint16(0x3c00), float32ToFloat16(1+1.0/2048))
+		require.Equal(t, uint16(0x3c02), float32ToFloat16(1+3.0/2048))
+		// Values past the largest representable value overflow to infinity.
+		require.Equal(t, uint16(0x7c00), float32ToFloat16(65520))
+		// Values too small to be represented flush to zero.
+		require.Equal(t, uint16(0x0000), float32ToFloat16(1e-10))
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		require.True(t, math.IsNaN(float64(float16ToFloat32(float32ToFloat16(float32(math.NaN()))))))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, f := range []float32{0.1, -0.25, 0.333, 0.9999} {
+			require.InDelta(t, f, float16ToFloat32(float32ToFloat16(f)), 1e-3)
+		}
+	})
+}
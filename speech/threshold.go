@@ -0,0 +1,83 @@
+package speech
+
+import "fmt"
+
+// The number of bins of the speech probability histogram SuggestThreshold is based on.
+const thresholdHistogramBins = 100
+
+// SuggestThreshold runs inference over pcm and returns a speech probability threshold
+// suited to it, found by applying Otsu's method to the histogram of the probabilities of
+// its windows: the returned value best separates them into a silence and a speech mode.
+// The configuration is left untouched, the suggestion can be applied with SetThreshold.
+// If the probabilities don't split into two modes, e.g. all windows have the same one,
+// the configured threshold is returned. As with Detect, the model state carries over
+// between calls so Reset should be called before processing unrelated audio.
+func (sd *Detector) SuggestThreshold(pcm []float32) (float32, error) {
+	if sd == nil {
+		return 0, fmt.Errorf("invalid nil detector")
+	}
+
+	pcm = sd.prepareInput(pcm)
+	if err := sd.checkInputLen(len(pcm)); err != nil {
+		return 0, err
+	}
+
+	var histogram [thresholdHistogramBins]int
+	err := sd.inferWindows(pcm, func(speechProb float32) error {
+		bin := int(speechProb * thresholdHistogramBins)
+		histogram[min(max(bin, 0), thresholdHistogramBins-1)]++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	threshold, ok := otsuThreshold(histogram[:])
+	if !ok {
+		return sd.cfg.Threshold, nil
+	}
+	return threshold, nil
+}
+
+// otsuThreshold returns the threshold, in the [0, 1] range the histogram bins evenly
+// cover, maximizing the between-class variance of the values below and above it. When
+// several bin edges do so, as is the case for the empty bins of a valley between the
+// two modes, the threshold is placed halfway between the first and last of them.
+// It reports false if no threshold separates the values, e.g. when they're all in the
+// same bin.
+func otsuThreshold(histogram []int) (float32, bool) {
+	bins := len(histogram)
+
+	var total, sum float64
+	for i, count := range histogram {
+		total += float64(count)
+		sum += (float64(i) + 0.5) * float64(count)
+	}
+
+	var weightBelow, sumBelow, best float64
+	first, last := -1, -1
+	for i := 0; i < bins-1; i++ {
+		weightBelow += float64(histogram[i])
+		sumBelow += (float64(i) + 0.5) * float64(histogram[i])
+
+		weightAbove := total - weightBelow
+		if weightBelow == 0 || weightAbove == 0 {
+			continue
+		}
+
+		meanDiff := sumBelow/weightBelow - (sum-sumBelow)/weightAbove
+		variance := weightBelow * weightAbove * meanDiff * meanDiff
+		if variance > best {
+			best, first, last = variance, i, i
+		} else if variance == best && last == i-1 {
+			last = i
+		}
+	}
+
+	if first < 0 {
+		return 0, false
+	}
+
+	// The edge above bin i is at (i+1)/bins.
+	return float32(first+last+2) / float32(2*bins), true
+}
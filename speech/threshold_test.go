@@ -0,0 +1,42 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOtsuThreshold(t *testing.T) {
+	t.Run("two modes", func(t *testing.T) {
+		histogram := make([]int, 100)
+		histogram[5], histogram[8], histogram[10] = 10, 20, 5
+		histogram[80], histogram[85], histogram[90] = 5, 20, 10
+
+		// Halfway within the valley, between the edges above bin 10 and below bin 80.
+		threshold, ok := otsuThreshold(histogram)
+		require.True(t, ok)
+		require.InDelta(t, 0.455, threshold, 1e-6)
+	})
+
+	t.Run("unbalanced modes", func(t *testing.T) {
+		histogram := make([]int, 10)
+		histogram[1], histogram[2] = 50, 30
+		histogram[7] = 5
+
+		threshold, ok := otsuThreshold(histogram)
+		require.True(t, ok)
+		require.Greater(t, threshold, float32(0.3))
+		require.LessOrEqual(t, threshold, float32(0.7))
+	})
+
+	t.Run("single mode", func(t *testing.T) {
+		histogram := make([]int, 100)
+		histogram[42] = 100
+
+		_, ok := otsuThreshold(histogram)
+		require.False(t, ok)
+
+		_, ok = otsuThreshold(make([]int, 100))
+		require.False(t, ok)
+	})
+}
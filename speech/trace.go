@@ -0,0 +1,142 @@
+package speech
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The bound probabilities are clamped to before converting them to logits.
+const logitEpsilon = 1e-7
+
+// WindowDecision describes how a single window was handled by the segmentation
+// state machine.
+type WindowDecision struct {
+	// The position in samples of the end of the window, counted from the creation
+	// of the detector or the last Reset. It includes any padding added because
+	// of PadShortInput.
+	Sample int
+	// The speech probability of the window, averaged with the previous ones when
+	// ProbSmoothingWindows is set.
+	Probability float32
+	// Whether a speech segment is in progress after the window.
+	Triggered bool
+	// Whether the window started or ended a speech segment.
+	TriggerChanged bool
+	// The position in samples at which the silence that may end the current segment
+	// began, or zero if there is none.
+	TempEnd int
+}
+
+// DetectWithTrace works like Detect but also returns the decision taken for each
+// window processed, which is useful to test logic built on top of the segmentation.
+func (sd *Detector) DetectWithTrace(pcm []float32) ([]Segment, []WindowDecision, error) {
+	if sd == nil {
+		return nil, nil, fmt.Errorf("invalid nil detector")
+	}
+
+	var trace []WindowDecision
+	segments, err := sd.detect(pcm, func(decision WindowDecision) {
+		trace = append(trace, decision)
+	})
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, nil, err
+	}
+
+	return segments, trace, err
+}
+
+// DetectDetailed works like Detect but also returns the speech probability of each
+// window processed, in a single inference pass. The probabilities are ordered as the
+// windows in pcm, preceded and followed by those of the silence added when padding
+// short inputs (see PadShortInput), and are averaged when ProbSmoothingWindows is set,
+// matching the values compared to the thresholds. When OutputType is OutputTypeLogit
+// their logits are returned instead.
+func (sd *Detector) DetectDetailed(pcm []float32) ([]Segment, []float32, error) {
+	if sd == nil {
+		return nil, nil, fmt.Errorf("invalid nil detector")
+	}
+
+	segments, probs, err := sd.DetectDetailedAppend(make([]float32, 0, len(pcm)/sd.windowSize()), pcm)
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, nil, err
+	}
+
+	return segments, probs, err
+}
+
+// DetectDetailedAppend works like DetectDetailed but appends the probabilities to
+// probs, growing it as needed, and returns the extended slice. Reusing the buffer
+// across calls, e.g. as probs[:0], avoids allocating it for each input. On failure the
+// returned slice holds the original elements of probs only.
+func (sd *Detector) DetectDetailedAppend(probs, pcm []float32) ([]Segment, []float32, error) {
+	if sd == nil {
+		return nil, probs, fmt.Errorf("invalid nil detector")
+	}
+
+	n := len(probs)
+	segments, err := sd.detect(pcm, func(decision WindowDecision) {
+		prob := decision.Probability
+		if sd.cfg.OutputType == OutputTypeLogit {
+			prob = logit(prob)
+		}
+		probs = append(probs, prob)
+	})
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, probs[:n], err
+	}
+
+	return segments, probs, err
+}
+
+// ProbPoint is the speech probability of a single window, as returned by
+// DetectProbPoints.
+type ProbPoint struct {
+	// The timestamp in seconds of the start of the window, on the timeline of segments.
+	Time float64
+	// The speech probability of the window, averaged when ProbSmoothingWindows is set.
+	Prob float32
+}
+
+// DetectProbPoints works like Detect but also returns the speech probability of the
+// windows whose probability is at or above minProb, in order. Unlike the probabilities
+// of every window returned by DetectDetailed, the output size then depends on the
+// amount of speech-like activity, e.g. to index where it occurs across long archives.
+// The windows of the silence added by PadShortInput are left out, and OutputType
+// doesn't apply.
+func (sd *Detector) DetectProbPoints(pcm []float32, minProb float32) ([]Segment, []ProbPoint, error) {
+	if sd == nil {
+		return nil, nil, fmt.Errorf("invalid nil detector")
+	}
+
+	if minProb < 0 || minProb > 1 {
+		return nil, nil, fmt.Errorf("invalid minProb: should be in range [0, 1]")
+	}
+
+	windowSize := sd.windowSize()
+	callStart := sd.currSample
+	padSamples := sd.shortInputPad(len(pcm))
+
+	var points []ProbPoint
+	segments, err := sd.detect(pcm, func(decision WindowDecision) {
+		start := decision.Sample - windowSize - padSamples
+		if decision.Probability < minProb || start < callStart || start >= callStart+len(pcm) {
+			return
+		}
+		points = append(points, ProbPoint{
+			Time: float64(start)/float64(sd.cfg.SampleRate) + sd.cfg.StartOffsetSec + sd.rateChangeOffsetSec,
+			Prob: decision.Probability,
+		})
+	})
+	if err != nil && !errors.Is(err, ErrDetectorClosed) {
+		return nil, nil, err
+	}
+
+	return segments, points, err
+}
+
+// logit returns the logit of the probability p, the inverse of the sigmoid function.
+func logit(p float32) float32 {
+	p64 := min(max(float64(p), logitEpsilon), 1-logitEpsilon)
+	return float32(math.Log(p64 / (1 - p64)))
+}
@@ -0,0 +1,242 @@
+package speech
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectWithTrace(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	expected, err := sd.Detect(samples)
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+
+	err = sd.Reset()
+	require.NoError(t, err)
+
+	segments, trace, err := sd.DetectWithTrace(samples)
+	require.NoError(t, err)
+	require.Equal(t, expected, segments)
+	require.Len(t, trace, sd.Stats().Windows)
+
+	var triggered bool
+	var changes int
+	for i, decision := range trace {
+		require.Equal(t, (i+1)*512, decision.Sample)
+		require.GreaterOrEqual(t, decision.Probability, float32(0))
+		require.LessOrEqual(t, decision.Probability, float32(1))
+		require.Equal(t, decision.Triggered != triggered, decision.TriggerChanged)
+		if decision.TriggerChanged {
+			changes++
+		}
+		if !decision.Triggered {
+			require.Zero(t, decision.TempEnd)
+		}
+		triggered = decision.Triggered
+	}
+
+	// Segments filtered out for being too short changed the state too.
+	require.GreaterOrEqual(t, changes, 2*len(segments)-1)
+}
+
+func TestDetectDetailed(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	expected, trace, err := sd.DetectWithTrace(samples)
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+
+	err = sd.Reset()
+	require.NoError(t, err)
+
+	segments, probs, err := sd.DetectDetailed(samples)
+	require.NoError(t, err)
+	require.Equal(t, expected, segments)
+	require.Len(t, probs, len(samples)/512)
+	for i, decision := range trace {
+		require.Equal(t, decision.Probability, probs[i])
+	}
+
+	var nilDetector *Detector
+	_, _, err = nilDetector.DetectDetailed(samples)
+	require.EqualError(t, err, "invalid nil detector")
+}
+
+func TestDetectDetailedAppend(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	expected, expectedProbs, err := sd.DetectDetailed(samples)
+	require.NoError(t, err)
+
+	// Probabilities are appended to the existing elements.
+	require.NoError(t, sd.Reset())
+	segments, probs, err := sd.DetectDetailedAppend([]float32{42}, samples)
+	require.NoError(t, err)
+	require.Equal(t, expected, segments)
+	require.Equal(t, append([]float32{42}, expectedProbs...), probs)
+
+	// A buffer with enough capacity is reused.
+	buf := make([]float32, 0, len(expectedProbs))
+	require.NoError(t, sd.Reset())
+	_, probs, err = sd.DetectDetailedAppend(buf, samples)
+	require.NoError(t, err)
+	require.Equal(t, expectedProbs, probs)
+	require.Same(t, &buf[:1][0], &probs[0])
+
+	// On failure the buffer is returned as it was.
+	_, probs, err = sd.DetectDetailedAppend(probs[:1], samples[:100])
+	require.Error(t, err)
+	require.Len(t, probs, 1)
+}
+
+func TestDetectProbPoints(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	expected, probs, err := sd.DetectDetailed(samples)
+	require.NoError(t, err)
+
+	// Only the windows at or above minProb are reported, timestamped at their start.
+	var want []ProbPoint
+	for i, prob := range probs {
+		if prob >= 0.5 {
+			want = append(want, ProbPoint{Time: float64(i*512) / 16000, Prob: prob})
+		}
+	}
+	require.NotEmpty(t, want)
+	require.Less(t, len(want), len(probs))
+
+	require.NoError(t, sd.Reset())
+	segments, points, err := sd.DetectProbPoints(samples, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, expected, segments)
+	require.Equal(t, want, points)
+
+	// A zero minimum reports every window.
+	require.NoError(t, sd.Reset())
+	_, points, err = sd.DetectProbPoints(samples, 0)
+	require.NoError(t, err)
+	require.Len(t, points, len(probs))
+
+	t.Run("padded", func(t *testing.T) {
+		cfg := cfg
+		cfg.PadShortInput = true
+		cfg.StartOffsetSec = 10
+		sdPad, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdPad.Destroy())
+		}()
+
+		short := samples[:8000]
+		_, points, err := sdPad.DetectProbPoints(short, 0)
+		require.NoError(t, err)
+		// The windows of the padding are left out.
+		require.Len(t, points, (len(short)+511)/512)
+		require.Equal(t, 10.0, points[0].Time)
+	})
+
+	_, _, err = sd.DetectProbPoints(samples, 1.5)
+	require.EqualError(t, err, "invalid minProb: should be in range [0, 1]")
+}
+
+func TestDetectDetailedLogit(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	expected, expectedProbs, err := sd.DetectDetailed(samples)
+	require.NoError(t, err)
+
+	cfg.OutputType = OutputTypeLogit
+	sdLogit, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sdLogit)
+	defer func() {
+		require.NoError(t, sdLogit.Destroy())
+	}()
+
+	// Segmentation is unaffected, the logits mapping back to the probabilities.
+	segments, logits, err := sdLogit.DetectDetailed(samples)
+	require.NoError(t, err)
+	require.Equal(t, expected, segments)
+	require.Len(t, logits, len(expectedProbs))
+	for i, l := range logits {
+		require.InDelta(t, expectedProbs[i], 1/(1+math.Exp(-float64(l))), 1e-5)
+	}
+}
+
+func TestLogit(t *testing.T) {
+	require.Zero(t, logit(0.5))
+	require.InDelta(t, math.Log(3), logit(0.75), 1e-6)
+	require.InDelta(t, -math.Log(3), logit(0.25), 1e-6)
+
+	// Probabilities at the bounds give finite values.
+	require.InDelta(t, -16.118, logit(0), 1e-3)
+	require.InDelta(t, 16.118, logit(1), 1e-3)
+}
@@ -0,0 +1,75 @@
+package speech
+
+import "fmt"
+
+// TransitionDirection tells whether a transition starts or ends speech.
+type TransitionDirection int
+
+const (
+	// Speech starts, following silence.
+	TransitionSpeechStart TransitionDirection = iota + 1
+	// Speech ends, followed by silence.
+	TransitionSpeechEnd
+)
+
+// String returns the name of the direction.
+func (d TransitionDirection) String() string {
+	switch d {
+	case TransitionSpeechStart:
+		return "speech start"
+	case TransitionSpeechEnd:
+		return "speech end"
+	default:
+		return fmt.Sprintf("TransitionDirection(%d)", int(d))
+	}
+}
+
+// Transition is a change between silence and speech.
+type Transition struct {
+	// The timestamp in seconds of the transition.
+	At float64
+	// Whether speech starts or ends.
+	Direction TransitionDirection
+}
+
+// Transitions returns the transitions between silence and speech delimiting the
+// segments, expected in order, in chronological order. Segments overlapping or touching
+// each other, e.g. once padded, are merged, as there is no silence between them.
+// Segments that have no end yet only contribute their start.
+func (s Segments) Transitions() []Transition {
+	transitions := make([]Transition, 0, 2*len(s))
+	for _, segment := range s {
+		if n := len(transitions); n > 0 && transitions[n-1].Direction == TransitionSpeechEnd && segment.SpeechStartAt <= transitions[n-1].At {
+			// Speech goes on, until the later of the two ends.
+			end := transitions[n-1].At
+			transitions = transitions[:n-1]
+			if !segment.Unfinished {
+				transitions = append(transitions, Transition{At: max(end, segment.SpeechEndAt), Direction: TransitionSpeechEnd})
+			}
+			continue
+		}
+
+		transitions = append(transitions, Transition{At: segment.SpeechStartAt, Direction: TransitionSpeechStart})
+		if !segment.Unfinished {
+			transitions = append(transitions, Transition{At: segment.SpeechEndAt, Direction: TransitionSpeechEnd})
+		}
+	}
+	return transitions
+}
+
+// Transitions runs speech detection over pcm like Detect, returning the transitions
+// between silence and speech rather than the segments they delimit. As with Detect,
+// segments filtered out for being too short don't produce transitions, and speech still
+// in progress at the end of the input has no end transition.
+func (sd *Detector) Transitions(pcm []float32) ([]Transition, error) {
+	if sd == nil {
+		return nil, fmt.Errorf("invalid nil detector")
+	}
+
+	segments, err := sd.Detect(pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	return Segments(segments).Transitions(), nil
+}
@@ -0,0 +1,94 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentsTransitions(t *testing.T) {
+	segments := Segments{
+		{SpeechStartAt: 1, SpeechEndAt: 2},
+		{SpeechStartAt: 3.5, SpeechEndAt: 4},
+		{SpeechStartAt: 5, Unfinished: true},
+	}
+
+	require.Equal(t, []Transition{
+		{At: 1, Direction: TransitionSpeechStart},
+		{At: 2, Direction: TransitionSpeechEnd},
+		{At: 3.5, Direction: TransitionSpeechStart},
+		{At: 4, Direction: TransitionSpeechEnd},
+		{At: 5, Direction: TransitionSpeechStart},
+	}, segments.Transitions())
+
+	require.Empty(t, Segments(nil).Transitions())
+
+	// Overlapping and touching segments are merged.
+	require.Equal(t, []Transition{
+		{At: 1, Direction: TransitionSpeechStart},
+		{At: 3, Direction: TransitionSpeechEnd},
+		{At: 4, Direction: TransitionSpeechStart},
+	}, Segments{
+		{SpeechStartAt: 1, SpeechEndAt: 2.5},
+		{SpeechStartAt: 2, SpeechEndAt: 2.25},
+		{SpeechStartAt: 2.5, SpeechEndAt: 3},
+		{SpeechStartAt: 4, SpeechEndAt: 5},
+		{SpeechStartAt: 4.5, Unfinished: true},
+	}.Transitions())
+
+	require.Equal(t, "speech start", TransitionSpeechStart.String())
+	require.Equal(t, "speech end", TransitionSpeechEnd.String())
+	require.Equal(t, "TransitionDirection(42)", TransitionDirection(42).String())
+}
+
+func TestDetectorTransitions(t *testing.T) {
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	for _, tc := range []struct {
+		name        string
+		speechPadMs int
+	}{
+		{name: "unpadded"},
+		// Padded by more than half the minimum silence, so that segments overlap.
+		{name: "overlapping", speechPadMs: 1000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sd, err := NewDetector(DetectorConfig{
+				ModelPath:   "../testfiles/silero_vad.onnx",
+				SampleRate:  16000,
+				Threshold:   0.5,
+				SpeechPadMs: tc.speechPadMs,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, sd)
+			defer func() {
+				require.NoError(t, sd.Destroy())
+			}()
+
+			segments, err := sd.Detect(samples)
+			require.NoError(t, err)
+			require.NotEmpty(t, segments)
+
+			require.NoError(t, sd.Reset())
+			transitions, err := sd.Transitions(samples)
+			require.NoError(t, err)
+			require.Equal(t, Segments(segments).Transitions(), transitions)
+
+			// Transitions alternate, starting with speech.
+			for i, transition := range transitions {
+				if i%2 == 0 {
+					require.Equal(t, TransitionSpeechStart, transition.Direction)
+				} else {
+					require.Equal(t, TransitionSpeechEnd, transition.Direction)
+				}
+				if i > 0 {
+					require.Greater(t, transition.At, transitions[i-1].At)
+				}
+			}
+
+			var nilDetector *Detector
+			_, err = nilDetector.Transitions(samples)
+			require.EqualError(t, err, "invalid nil detector")
+		})
+	}
+}
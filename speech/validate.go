@@ -0,0 +1,103 @@
+package speech
+
+// #include "ort_bridge.h"
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// ValidateModel checks that the model at path can be loaded by ONNX Runtime and has
+// the input and output signature of a Silero VAD model, either with a single state
+// tensor (input, state, sr -> output, stateN) or with split h and c state tensors
+// (input, sr, h, c -> output, hn, cn). All the resources it allocates are released
+// before returning, making it suitable as a quick pre-flight check of model assets.
+func ValidateModel(path string) error {
+	if err := checkBridgeVersion(); err != nil {
+		return err
+	}
+
+	api := C.OrtGetApi()
+	if api == nil {
+		return fmt.Errorf("failed to get API")
+	}
+
+	loggerName := C.CString("vad")
+	defer C.free(unsafe.Pointer(loggerName))
+
+	var env *C.OrtEnv
+	status := C.OrtApiCreateEnv(api, LogLevelError.OrtLoggingLevel(), loggerName, &env)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create env: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+	}
+	defer C.OrtApiReleaseEnv(api, env)
+
+	var sessionOpts *C.OrtSessionOptions
+	status = C.OrtApiCreateSessionOptions(api, &sessionOpts)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create session options: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+	}
+	defer C.OrtApiReleaseSessionOptions(api, sessionOpts)
+
+	modelPath := C.CString(path)
+	defer C.free(unsafe.Pointer(modelPath))
+
+	var session *C.OrtSession
+	status = C.OrtApiCreateSession(api, env, modelPath, sessionOpts, &session)
+	defer C.OrtApiReleaseStatus(api, status)
+	if status != nil {
+		return fmt.Errorf("failed to create session: %s", C.GoString(C.OrtApiGetErrorMessage(api, status)))
+	}
+	defer C.OrtApiReleaseSession(api, session)
+
+	inputNames, err := sessionInputNames(api, session)
+	if err != nil {
+		return err
+	}
+	outputNames, err := sessionOutputNames(api, session)
+	if err != nil {
+		return err
+	}
+
+	return validateSignature(inputNames, outputNames)
+}
+
+// validateSignature checks that the given model inputs and outputs match those of a
+// Silero VAD model.
+func validateSignature(inputNames, outputNames []string) error {
+	wantInputs := []string{"input", "state", "sr"}
+	wantOutputs := []string{"output", "stateN"}
+	if hasSplitState(inputNames) {
+		wantInputs = []string{"input", "sr", "h", "c"}
+		wantOutputs = []string{"output", "hn", "cn"}
+	}
+
+	if !sameNames(inputNames, wantInputs) {
+		return fmt.Errorf("invalid model inputs %q: should be %q", inputNames, wantInputs)
+	}
+	if !sameNames(outputNames, wantOutputs) {
+		return fmt.Errorf("invalid model outputs %q: should be %q", outputNames, wantOutputs)
+	}
+
+	return nil
+}
+
+// sameNames reports whether a and b hold the same names, regardless of their order.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, name := range b {
+		var found bool
+		for _, n := range a {
+			found = found || n == name
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package speech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateModel(t *testing.T) {
+	require.NoError(t, ValidateModel("../testfiles/silero_vad.onnx"))
+
+	err := ValidateModel("../testfiles/missing.onnx")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to create session")
+}
+
+func TestValidateSignature(t *testing.T) {
+	tcs := []struct {
+		name        string
+		inputNames  []string
+		outputNames []string
+		err         string
+	}{
+		{
+			name:        "single state",
+			inputNames:  []string{"input", "state", "sr"},
+			outputNames: []string{"output", "stateN"},
+		},
+		{
+			name:        "split state",
+			inputNames:  []string{"input", "sr", "h", "c"},
+			outputNames: []string{"output", "hn", "cn"},
+		},
+		{
+			name:        "reordered",
+			inputNames:  []string{"sr", "input", "state"},
+			outputNames: []string{"stateN", "output"},
+		},
+		{
+			name:        "missing input",
+			inputNames:  []string{"input", "state"},
+			outputNames: []string{"output", "stateN"},
+			err:         `invalid model inputs ["input" "state"]: should be ["input" "state" "sr"]`,
+		},
+		{
+			name:        "unexpected output",
+			inputNames:  []string{"input", "sr", "h", "c"},
+			outputNames: []string{"output", "stateN"},
+			err:         `invalid model outputs ["output" "stateN"]: should be ["output" "hn" "cn"]`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSignature(tc.inputNames, tc.outputNames)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package speech
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+// isWAV reports whether data starts with a RIFF WAVE header.
+func isWAV(data []byte) bool {
+	return len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE"))
+}
+
+// DecodeWAV decodes a WAV file holding 16-bit integer or 32-bit float PCM samples,
+// returning the samples along with their sample rate. Multichannel audio is mixed
+// down to mono by averaging the channels.
+func DecodeWAV(data []byte) ([]float32, int, error) {
+	if !isWAV(data) {
+		return nil, 0, fmt.Errorf("invalid WAV data: missing RIFF header")
+	}
+
+	var (
+		format     SampleFormat
+		channels   int
+		sampleRate int
+		pcm        []byte
+		foundFmt   bool
+		foundData  bool
+	)
+
+	for rest := data[12:]; len(rest) >= 8 && !foundData; {
+		id := string(rest[0:4])
+		declared := binary.LittleEndian.Uint32(rest[4:8])
+		rest = rest[8:]
+		// Streamed files may not know the final size of their data. The size is
+		// clamped before converting it, as it may not fit in an int.
+		size := len(rest)
+		if uint64(declared) < uint64(size) {
+			size = int(declared)
+		}
+		chunk := rest[:size]
+
+		switch id {
+		case "fmt ":
+			if len(chunk) < 16 {
+				return nil, 0, fmt.Errorf("invalid WAV data: fmt chunk too short")
+			}
+			audioFormat := int(binary.LittleEndian.Uint16(chunk[0:2]))
+			channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bitsPerSample := int(binary.LittleEndian.Uint16(chunk[14:16]))
+			if audioFormat == wavFormatExtensible && len(chunk) >= 26 {
+				// The actual format is at the start of the sub-format GUID.
+				audioFormat = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+
+			switch {
+			case audioFormat == wavFormatPCM && bitsPerSample == 16:
+				format = SampleFormatInt16LE
+			case audioFormat == wavFormatFloat && bitsPerSample == 32:
+				format = SampleFormatFloat32LE
+			default:
+				return nil, 0, fmt.Errorf("unsupported WAV format %d with %d bits per sample", audioFormat, bitsPerSample)
+			}
+			if channels < 1 {
+				return nil, 0, fmt.Errorf("invalid WAV data: no channels")
+			}
+			if sampleRate <= 0 {
+				return nil, 0, fmt.Errorf("invalid WAV data: invalid sample rate")
+			}
+			foundFmt = true
+		case "data":
+			if !foundFmt {
+				return nil, 0, fmt.Errorf("invalid WAV data: data chunk before fmt chunk")
+			}
+			pcm = chunk
+			foundData = true
+		}
+
+		// Chunks are padded to an even size.
+		rest = rest[min(size+size%2, len(rest)):]
+	}
+
+	if !foundData {
+		return nil, 0, fmt.Errorf("invalid WAV data: missing data chunk")
+	}
+
+	// Ignore a trailing partial frame.
+	frameSize := format.Width() * channels
+	samples, err := DecodeSamples(pcm[:len(pcm)-len(pcm)%frameSize], format)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if channels > 1 {
+		mono := make([]float32, len(samples)/channels)
+		for i := range mono {
+			var sum float32
+			for _, s := range samples[i*channels : (i+1)*channels] {
+				sum += s
+			}
+			mono[i] = sum / float32(channels)
+		}
+		samples = mono
+	}
+
+	return samples, sampleRate, nil
+}
@@ -0,0 +1,368 @@
+package speech
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skypro1111/silero-vad-go/audioutil"
+)
+
+// encodeWAV builds a WAV file with a fmt chunk of the given parameters followed
+// by the data chunk.
+func encodeWAV(audioFormat, channels, sampleRate, bitsPerSample int, pcm []byte) []byte {
+	blockAlign := channels * bitsPerSample / 8
+
+	fmtChunk := binary.LittleEndian.AppendUint16(nil, uint16(audioFormat))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channels))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate*blockAlign))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(blockAlign))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bitsPerSample))
+	if audioFormat == wavFormatExtensible {
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 22)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bitsPerSample))
+		fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, 0)
+		// KSDATAFORMAT_SUBTYPE_IEEE_FLOAT
+		fmtChunk = append(fmtChunk, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71)
+	}
+
+	data := []byte("RIFF")
+	data = binary.LittleEndian.AppendUint32(data, uint32(4+8+len(fmtChunk)+8+len(pcm)))
+	data = append(data, "WAVE"...)
+	// An unrelated chunk with an odd size, which gets padded.
+	data = append(data, "LIST"...)
+	data = binary.LittleEndian.AppendUint32(data, 3)
+	data = append(data, 'a', 'b', 'c', 0)
+	data = append(data, "fmt "...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(fmtChunk)))
+	data = append(data, fmtChunk...)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(pcm)))
+	return append(data, pcm...)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	t.Run("int16 mono", func(t *testing.T) {
+		var pcm []byte
+		for _, v := range []int16{0, 16384, -32768, 32767} {
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+		}
+
+		samples, sampleRate, err := DecodeWAV(encodeWAV(wavFormatPCM, 1, 16000, 16, pcm))
+		require.NoError(t, err)
+		require.Equal(t, 16000, sampleRate)
+		require.Equal(t, []float32{0, 0.5, -1, 32767.0 / 32768}, samples)
+	})
+
+	t.Run("float32 stereo", func(t *testing.T) {
+		var pcm []byte
+		for _, v := range []float32{0.5, 0.25, -1, 1, 0.1, 0.1} {
+			pcm = binary.LittleEndian.AppendUint32(pcm, math.Float32bits(v))
+		}
+
+		samples, sampleRate, err := DecodeWAV(encodeWAV(wavFormatFloat, 2, 8000, 32, pcm))
+		require.NoError(t, err)
+		require.Equal(t, 8000, sampleRate)
+		require.InDeltaSlice(t, []float32{0.375, 0, 0.1}, samples, 1e-6)
+	})
+
+	t.Run("extensible", func(t *testing.T) {
+		pcm := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.75))
+
+		samples, _, err := DecodeWAV(encodeWAV(wavFormatExtensible, 1, 16000, 32, pcm))
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.75}, samples)
+	})
+
+	t.Run("partial frame", func(t *testing.T) {
+		samples, _, err := DecodeWAV(encodeWAV(wavFormatPCM, 1, 16000, 16, []byte{0, 64, 1}))
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.5}, samples)
+	})
+
+	t.Run("oversized chunk", func(t *testing.T) {
+		// A data chunk size larger than the file, as written by some streaming
+		// encoders, which doesn't fit in an int on 32-bit platforms.
+		data := encodeWAV(wavFormatPCM, 1, 16000, 16, []byte{0, 64})
+		binary.LittleEndian.PutUint32(data[len(data)-6:], math.MaxUint32)
+
+		samples, _, err := DecodeWAV(data)
+		require.NoError(t, err)
+		require.Equal(t, []float32{0.5}, samples)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, _, err := DecodeWAV(encodeWAV(wavFormatPCM, 1, 16000, 24, make([]byte, 6)))
+		require.EqualError(t, err, "unsupported WAV format 1 with 24 bits per sample")
+	})
+
+	t.Run("zero sample rate", func(t *testing.T) {
+		_, _, err := DecodeWAV(encodeWAV(wavFormatPCM, 1, 0, 16, make([]byte, 4)))
+		require.EqualError(t, err, "invalid WAV data: invalid sample rate")
+	})
+
+	t.Run("not a WAV", func(t *testing.T) {
+		_, _, err := DecodeWAV([]byte("not a wav file"))
+		require.EqualError(t, err, "invalid WAV data: missing RIFF header")
+	})
+
+	t.Run("missing data", func(t *testing.T) {
+		data := encodeWAV(wavFormatPCM, 1, 16000, 16, nil)
+		_, _, err := DecodeWAV(data[:len(data)-8])
+		require.EqualError(t, err, "invalid WAV data: missing data chunk")
+	})
+}
+
+func TestDetectFile(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	sd, err := NewDetector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+	defer func() {
+		require.NoError(t, sd.Destroy())
+	}()
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	t.Run("raw", func(t *testing.T) {
+		require.NoError(t, sd.Reset())
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		segments, err := sd.DetectFile("../testfiles/samples.pcm")
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("wav", func(t *testing.T) {
+		var pcm []byte
+		for _, s := range samples {
+			pcm = binary.LittleEndian.AppendUint32(pcm, math.Float32bits(s))
+		}
+		path := filepath.Join(t.TempDir(), "samples.wav")
+		require.NoError(t, os.WriteFile(path, encodeWAV(wavFormatFloat, 1, 16000, 32, pcm), 0o600))
+
+		require.NoError(t, sd.Reset())
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		segments, err := sd.DetectFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("aiff", func(t *testing.T) {
+		// An AIFF-C file holding the samples as big-endian float32 at 16000 Hz.
+		comm := []byte{0, 1}
+		comm = binary.BigEndian.AppendUint32(comm, uint32(len(samples)))
+		comm = append(comm, 0, 32, 0x40, 0x0c, 0xfa, 0, 0, 0, 0, 0, 0, 0)
+		comm = append(comm, "fl32"...)
+		comm = append(comm, 0, 0)
+		ssnd := make([]byte, 8)
+		for _, s := range samples {
+			ssnd = binary.BigEndian.AppendUint32(ssnd, math.Float32bits(s))
+		}
+		data := []byte("AIFC")
+		data = append(data, "COMM"...)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(comm)))
+		data = append(data, comm...)
+		data = append(data, "SSND"...)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(ssnd)))
+		data = append(data, ssnd...)
+		data = append(binary.BigEndian.AppendUint32([]byte("FORM"), uint32(len(data))), data...)
+
+		path := filepath.Join(t.TempDir(), "samples.aiff")
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		require.NoError(t, sd.Reset())
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Reset())
+		segments, err := sd.DetectFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("sample rate mismatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "samples.wav")
+		require.NoError(t, os.WriteFile(path, encodeWAV(wavFormatPCM, 1, 8000, 16, make([]byte, 16000)), 0o600))
+
+		_, err := sd.DetectFile(path)
+		require.EqualError(t, err, "invalid sample rate: file is 8000 Hz but the detector expects 16000 Hz")
+	})
+
+	t.Run("resample", func(t *testing.T) {
+		// The samples at 8000 Hz, resampled back to 16000 Hz when read.
+		resampled := audioutil.Resample(samples, 16000, 8000)
+		var pcm []byte
+		for _, s := range resampled {
+			pcm = binary.LittleEndian.AppendUint32(pcm, math.Float32bits(s))
+		}
+		path := filepath.Join(t.TempDir(), "samples.wav")
+		require.NoError(t, os.WriteFile(path, encodeWAV(wavFormatFloat, 1, 8000, 32, pcm), 0o600))
+
+		cfg := cfg
+		cfg.ResampleFiles = true
+		sdResample, err := NewDetector(cfg)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, sdResample.Destroy())
+		}()
+
+		expected, err := sdResample.Detect(audioutil.Resample(resampled, 8000, 16000))
+		require.NoError(t, err)
+
+		require.NoError(t, sdResample.Reset())
+		segments, err := sdResample.DetectFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// A file declaring no sample rate is rejected rather than resampled.
+		require.NoError(t, os.WriteFile(path, encodeWAV(wavFormatFloat, 1, 0, 32, pcm), 0o600))
+		_, err = sdResample.DetectFile(path)
+		require.EqualError(t, err, "failed to decode WAV: invalid WAV data: invalid sample rate")
+	})
+
+	t.Run("header bytes", func(t *testing.T) {
+		data, err := os.ReadFile("../testfiles/samples.pcm")
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "samples.raw")
+		require.NoError(t, os.WriteFile(path, append([]byte("HDR\x00\x01\x02"), data...), 0o600))
+
+		cfg := cfg
+		cfg.HeaderBytes = 6
+		sdHeader, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sdHeader)
+		defer func() {
+			require.NoError(t, sdHeader.Destroy())
+		}()
+
+		require.NoError(t, sd.Reset())
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+
+		segments, err := sdHeader.DetectFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+
+		// The remaining data must hold whole samples.
+		require.NoError(t, os.WriteFile(path, append([]byte("HDR"), data...), 0o600))
+		_, err = sdHeader.DetectFile(path)
+		require.EqualError(t, err, "failed to decode samples: invalid data length: should be a multiple of 4")
+
+		require.NoError(t, os.WriteFile(path, []byte("HDR"), 0o600))
+		_, err = sdHeader.DetectFile(path)
+		require.EqualError(t, err, "invalid data length: should be at least HeaderBytes (6)")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := sd.DetectFile(filepath.Join(t.TempDir(), "missing.pcm"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestDetectConcat(t *testing.T) {
+	cfg := DetectorConfig{
+		ModelPath:  "../testfiles/silero_vad.onnx",
+		SampleRate: 16000,
+		Threshold:  0.5,
+	}
+
+	samples := readSamplesFromFile(t, "../testfiles/samples.pcm")
+
+	// writeRaw writes samples as a raw float32 file, returning its path.
+	writeRaw := func(t *testing.T, name string, samples []float32) string {
+		var data []byte
+		for _, s := range samples {
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(s))
+		}
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+
+	t.Run("continuous", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		expected, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+
+		// Split the audio off the window boundaries.
+		paths := []string{
+			writeRaw(t, "a.pcm", samples[:40000]),
+			writeRaw(t, "b.pcm", samples[40000:40100]),
+			writeRaw(t, "c.pcm", samples[40100:]),
+		}
+		segments, err := sd.DetectConcat(paths)
+		require.NoError(t, err)
+		require.Equal(t, expected, segments)
+	})
+
+	t.Run("reset between files", func(t *testing.T) {
+		cfg := cfg
+		cfg.ResetBetweenFiles = true
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		fileSegments, err := sd.Detect(samples)
+		require.NoError(t, err)
+		require.NotEmpty(t, fileSegments)
+
+		// Each file is processed from a clean state, starting where the previous one ends.
+		expected := append([]Segment(nil), fileSegments...)
+		duration := float64(len(samples)) / 16000
+		for _, segment := range fileSegments {
+			segment.SpeechStartAt += duration
+			if !segment.Unfinished {
+				segment.SpeechEndAt += duration
+			}
+			expected = append(expected, segment)
+		}
+
+		path := writeRaw(t, "samples.pcm", samples)
+		segments, err := sd.DetectConcat([]string{path, path})
+		require.NoError(t, err)
+		require.Equal(t, len(expected), len(segments))
+		for i := range expected {
+			require.InDelta(t, expected[i].SpeechStartAt, segments[i].SpeechStartAt, 1e-9)
+			require.InDelta(t, expected[i].SpeechEndAt, segments[i].SpeechEndAt, 1e-9)
+			require.Equal(t, expected[i].Unfinished, segments[i].Unfinished)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		sd, err := NewDetector(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, sd)
+		defer func() {
+			require.NoError(t, sd.Destroy())
+		}()
+
+		_, err = sd.DetectConcat([]string{filepath.Join(t.TempDir(), "missing.pcm")})
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}